@@ -15,6 +15,7 @@ import (
 	"pr-reviewer-service/internal/logger"
 	"pr-reviewer-service/internal/repository"
 	"pr-reviewer-service/internal/service"
+	"pr-reviewer-service/internal/tenant"
 
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
@@ -33,6 +34,9 @@ func main() {
 	log := logger.New(cfg.Logger.Level)
 	log.Info("Starting PR Reviewer Assignment Service")
 	log.Info("Configuration loaded successfully")
+	if !cfg.Auth.Required {
+		log.Warn("AUTH_REQUIRED is disabled: requests without API token use organization %s", tenant.DefaultOrg)
+	}
 
 	ctx := context.Background()
 	dbCfg := database.Config{
@@ -65,6 +69,8 @@ func main() {
 	// добавляем middleware для логирования и восстановления после паники
 	r.Use(loggingMiddleware(log))
 	r.Use(recoveryMiddleware(log))
+	// организация вызывающего по API-токену, все данные запроса ограничены ею
+	r.Use(handlers.Authenticate(cfg.Auth.Tokens, cfg.Auth.Required))
 
 	srv := &http.Server{
 		Addr:         fmt.Sprintf(":%s", cfg.Server.Port),
@@ -74,6 +80,13 @@ func main() {
 		IdleTimeout:  cfg.Server.IdleTimeout,
 	}
 
+	// фоновая проверка SLA ревью, останавливается при завершении сервиса
+	workerCtx, stopWorker := context.WithCancel(ctx)
+	defer stopWorker()
+	if cfg.Worker.SLACheckInterval > 0 {
+		go svc.RunSLAWorker(workerCtx, cfg.Worker.SLACheckInterval)
+	}
+
 	// запускаем сервер в отдельной горутине
 	go func() {
 		log.Info("Server starting on port %s", cfg.Server.Port)
@@ -88,6 +101,7 @@ func main() {
 	<-quit
 
 	log.Info("Server shutting down gracefully...")
+	stopWorker()
 
 	// graceful shutdown с таймаутом 30 секунд
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
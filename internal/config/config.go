@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -11,6 +12,8 @@ type Config struct {
 	Server   ServerConfig
 	Database DatabaseConfig
 	Logger   LoggerConfig
+	Worker   WorkerConfig
+	Auth     AuthConfig
 }
 
 type ServerConfig struct {
@@ -32,7 +35,24 @@ type LoggerConfig struct {
 	Level string
 }
 
+type WorkerConfig struct {
+	// интервал проверки SLA ревью, 0 - проверка отключена
+	SLACheckInterval time.Duration
+}
+
+type AuthConfig struct {
+	// API-токен -> организация вызывающего
+	Tokens map[string]string
+	// false - запросы без токена относятся к организации по умолчанию
+	Required bool
+}
+
 func Load() (*Config, error) {
+	tokens, err := parseAPITokens(os.Getenv("API_TOKENS"))
+	if err != nil {
+		return nil, fmt.Errorf("config validation failed: %w", err)
+	}
+
 	cfg := &Config{
 		Server: ServerConfig{
 			Port:         getEnv("PORT", "8080"),
@@ -50,6 +70,13 @@ func Load() (*Config, error) {
 		Logger: LoggerConfig{
 			Level: getEnv("LOG_LEVEL", "info"),
 		},
+		Worker: WorkerConfig{
+			SLACheckInterval: getDurationEnv("SLA_CHECK_INTERVAL", time.Minute),
+		},
+		Auth: AuthConfig{
+			Tokens:   tokens,
+			Required: getBoolEnv("AUTH_REQUIRED", false),
+		},
 	}
 
 	if err := cfg.Validate(); err != nil {
@@ -66,9 +93,40 @@ func (c *Config) Validate() error {
 	if c.Database.URL == "" {
 		return fmt.Errorf("database URL is required")
 	}
+	if c.Worker.SLACheckInterval < 0 {
+		return fmt.Errorf("SLA check interval must not be negative")
+	}
+	if c.Auth.Required && len(c.Auth.Tokens) == 0 {
+		return fmt.Errorf("API_TOKENS is required when AUTH_REQUIRED is set")
+	}
 	return nil
 }
 
+// parseAPITokens разбирает список "token:org_id,token:org_id"
+func parseAPITokens(value string) (map[string]string, error) {
+	tokens := make(map[string]string)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		token, orgID, ok := strings.Cut(entry, ":")
+		token, orgID = strings.TrimSpace(token), strings.TrimSpace(orgID)
+		if !ok || token == "" || orgID == "" {
+			return nil, fmt.Errorf("API_TOKENS entry must be token:org_id")
+		}
+		if len(orgID) > 64 {
+			return nil, fmt.Errorf("organization %s is longer than 64 characters", orgID)
+		}
+		if _, exists := tokens[token]; exists {
+			return nil, fmt.Errorf("duplicate token in API_TOKENS")
+		}
+		tokens[token] = orgID
+	}
+	return tokens, nil
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -85,6 +143,15 @@ func getIntEnv(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getBoolEnv(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolVal, err := strconv.ParseBool(value); err == nil {
+			return boolVal
+		}
+	}
+	return defaultValue
+}
+
 func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
 		if duration, err := time.ParseDuration(value); err == nil {
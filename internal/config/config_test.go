@@ -0,0 +1,59 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseAPITokens(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:  "пустое значение - токенов нет",
+			value: "",
+			want:  map[string]string{},
+		},
+		{
+			name:  "несколько токенов с пробелами",
+			value: " token-a:billing , token-b:search,",
+			want:  map[string]string{"token-a": "billing", "token-b": "search"},
+		},
+		{
+			name:    "запись без организации",
+			value:   "token-a",
+			wantErr: true,
+		},
+		{
+			name:    "пустой токен",
+			value:   ":billing",
+			wantErr: true,
+		},
+		{
+			name:    "повторяющийся токен",
+			value:   "token-a:billing,token-a:search",
+			wantErr: true,
+		},
+		{
+			name:    "слишком длинная организация",
+			value:   "token-a:" + strings.Repeat("o", 65),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseAPITokens(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseAPITokens() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseAPITokens() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
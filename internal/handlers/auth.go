@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"pr-reviewer-service/internal/models"
+	"pr-reviewer-service/internal/tenant"
+)
+
+// Authenticate определяет организацию вызывающего по API-токену из заголовка
+// "Authorization: Bearer <token>" (tokens: токен -> организация) и передает ее дальше в контексте запроса.
+// Без токена запрос относится к организации по умолчанию, если токен не обязателен (required = false).
+// Неизвестный токен отклоняется всегда. /health доступен без токена
+func Authenticate(tokens map[string]string, required bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/health" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			token, ok := bearerToken(r)
+			if !ok {
+				if required {
+					respondError(w, http.StatusUnauthorized, models.ErrCodeUnauthorized, "API token is required")
+					return
+				}
+				next.ServeHTTP(w, r.WithContext(tenant.WithOrg(r.Context(), tenant.DefaultOrg)))
+				return
+			}
+
+			orgID, ok := lookupToken(tokens, token)
+			if !ok {
+				respondError(w, http.StatusUnauthorized, models.ErrCodeUnauthorized, "invalid API token")
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(tenant.WithOrg(r.Context(), orgID)))
+		})
+	}
+}
+
+// токен из заголовка Authorization, false - заголовка нет
+func bearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return "", false
+	}
+	scheme, token, _ := strings.Cut(header, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", true
+	}
+	return strings.TrimSpace(token), true
+}
+
+// сравнение за постоянное время, чтобы по времени ответа нельзя было подобрать токен
+func lookupToken(tokens map[string]string, token string) (string, bool) {
+	if token == "" {
+		return "", false
+	}
+	orgID, found := "", false
+	for known, org := range tokens {
+		if subtle.ConstantTimeCompare([]byte(known), []byte(token)) == 1 {
+			orgID, found = org, true
+		}
+	}
+	return orgID, found
+}
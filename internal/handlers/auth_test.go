@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pr-reviewer-service/internal/tenant"
+)
+
+func TestAuthenticate(t *testing.T) {
+	tokens := map[string]string{"token-a": "billing", "token-b": "search"}
+
+	tests := []struct {
+		name       string
+		path       string
+		header     string
+		required   bool
+		wantStatus int
+		wantOrg    string
+	}{
+		{
+			name:       "организация по токену",
+			path:       "/stats",
+			header:     "Bearer token-b",
+			wantStatus: http.StatusOK,
+			wantOrg:    "search",
+		},
+		{
+			name:       "без токена - организация по умолчанию",
+			path:       "/stats",
+			wantStatus: http.StatusOK,
+			wantOrg:    tenant.DefaultOrg,
+		},
+		{
+			name:       "без токена, когда он обязателен",
+			path:       "/stats",
+			required:   true,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "неизвестный токен",
+			path:       "/team/get",
+			header:     "Bearer token-c",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "другая схема авторизации",
+			path:       "/team/get",
+			header:     "Basic token-a",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "health доступен без токена",
+			path:       "/health",
+			required:   true,
+			wantStatus: http.StatusOK,
+			wantOrg:    tenant.DefaultOrg,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotOrg string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotOrg = tenant.OrgFromContext(r.Context())
+			})
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			Authenticate(tokens, tt.required)(next).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if gotOrg != tt.wantOrg {
+				t.Errorf("organization = %q, want %q", gotOrg, tt.wantOrg)
+			}
+		})
+	}
+}
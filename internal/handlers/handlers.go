@@ -3,6 +3,7 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"pr-reviewer-service/internal/logger"
@@ -28,13 +29,37 @@ func (h *Handler) SetupRoutes(r *mux.Router) {
 	r.HandleFunc("/team/add", h.CreateTeam).Methods("POST")
 	r.HandleFunc("/team/update", h.UpdateTeam).Methods("POST")
 	r.HandleFunc("/team/get", h.GetTeam).Methods("GET")
+	r.HandleFunc("/team/setCodeowners", h.SetCodeowners).Methods("POST")
+	r.HandleFunc("/team/getCodeowners", h.GetCodeowners).Methods("GET")
+	r.HandleFunc("/team/deactivateUsers", h.DeactivateTeamUsers).Methods("POST")
+
+	r.HandleFunc("/repository/add", h.CreateRepository).Methods("POST")
+	r.HandleFunc("/repository/update", h.UpdateRepository).Methods("POST")
+	r.HandleFunc("/repository/get", h.GetRepository).Methods("GET")
 
 	r.HandleFunc("/users/setIsActive", h.SetUserActive).Methods("POST")
+	r.HandleFunc("/users/setMaxOpenReviews", h.SetUserMaxOpenReviews).Methods("POST")
 	r.HandleFunc("/users/getReview", h.GetUserReviews).Methods("GET")
+	r.HandleFunc("/users/addOutOfOffice", h.AddOutOfOffice).Methods("POST")
+	r.HandleFunc("/users/getOutOfOffice", h.GetOutOfOffice).Methods("GET")
+	r.HandleFunc("/users/deleteOutOfOffice", h.DeleteOutOfOffice).Methods("POST")
 
 	r.HandleFunc("/pullRequest/create", h.CreatePR).Methods("POST")
+	r.HandleFunc("/pullRequest/get", h.GetPR).Methods("GET")
+	r.HandleFunc("/pullRequest/preview", h.PreviewPR).Methods("POST")
 	r.HandleFunc("/pullRequest/merge", h.MergePR).Methods("POST")
+	r.HandleFunc("/pullRequest/close", h.ClosePR).Methods("POST")
+	r.HandleFunc("/pullRequest/reopen", h.ReopenPR).Methods("POST")
+	r.HandleFunc("/pullRequest/ready", h.MarkPRReady).Methods("POST")
 	r.HandleFunc("/pullRequest/reassign", h.ReassignReviewer).Methods("POST")
+	r.HandleFunc("/pullRequest/submitReview", h.SubmitReview).Methods("POST")
+	r.HandleFunc("/pullRequest/rerequest", h.RerequestReview).Methods("POST")
+	r.HandleFunc("/pullRequest/reviewRounds", h.GetReviewRounds).Methods("GET")
+	r.HandleFunc("/pullRequest/decline", h.DeclineReview).Methods("POST")
+	r.HandleFunc("/pullRequest/addReviewer", h.AddReviewer).Methods("POST")
+	r.HandleFunc("/pullRequest/removeReviewer", h.RemoveReviewer).Methods("POST")
+	r.HandleFunc("/pullRequest/assignment", h.GetAssignmentExplanations).Methods("GET")
+	r.HandleFunc("/pullRequest/slaEvents", h.GetSLAEvents).Methods("GET")
 
 	r.HandleFunc("/stats", h.GetStats).Methods("GET")
 
@@ -58,11 +83,20 @@ func parseErrorCode(err error) string {
 	errMsg := err.Error()
 	for _, code := range []string{
 		models.ErrCodeTeamExists,
+		models.ErrCodeRepoExists,
 		models.ErrCodePRExists,
 		models.ErrCodePRMerged,
+		models.ErrCodePRClosed,
+		models.ErrCodePRDraft,
+		models.ErrCodeNotApproved,
 		models.ErrCodeNotAssigned,
 		models.ErrCodeNoCandidate,
+		models.ErrCodeNoCapacity,
+		models.ErrCodeAlreadyAssigned,
+		models.ErrCodeInvalidReviewer,
 		models.ErrCodeNotFound,
+		models.ErrCodeBadRequest,
+		models.ErrCodeUnauthorized,
 	} {
 		if strings.HasPrefix(errMsg, code) {
 			return code
@@ -73,11 +107,14 @@ func parseErrorCode(err error) string {
 
 func getHTTPStatusForError(code string) int {
 	switch code {
-	case models.ErrCodeTeamExists, models.ErrCodePRExists:
+	case models.ErrCodeTeamExists, models.ErrCodeRepoExists, models.ErrCodePRExists, models.ErrCodeBadRequest:
 		return http.StatusBadRequest
+	case models.ErrCodeUnauthorized:
+		return http.StatusUnauthorized
 	case models.ErrCodeNotFound:
 		return http.StatusNotFound
-	case models.ErrCodePRMerged, models.ErrCodeNotAssigned, models.ErrCodeNoCandidate:
+	case models.ErrCodePRMerged, models.ErrCodePRClosed, models.ErrCodePRDraft, models.ErrCodeNotApproved, models.ErrCodeNotAssigned,
+		models.ErrCodeNoCandidate, models.ErrCodeNoCapacity, models.ErrCodeAlreadyAssigned, models.ErrCodeInvalidReviewer:
 		return http.StatusConflict
 	default:
 		return http.StatusInternalServerError
@@ -144,6 +181,114 @@ func (h *Handler) GetTeam(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, team)
 }
 
+func (h *Handler) CreateRepository(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateRepositoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "BAD_REQUEST", "Invalid request body")
+		return
+	}
+
+	repo, err := h.service.CreateRepository(r.Context(), req)
+	if err != nil {
+		code := parseErrorCode(err)
+		status := getHTTPStatusForError(code)
+		respondError(w, status, code, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, map[string]interface{}{"repository": repo})
+}
+
+func (h *Handler) UpdateRepository(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateRepositoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "BAD_REQUEST", "Invalid request body")
+		return
+	}
+
+	repo, err := h.service.UpdateRepository(r.Context(), req)
+	if err != nil {
+		code := parseErrorCode(err)
+		status := getHTTPStatusForError(code)
+		respondError(w, status, code, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"repository": repo})
+}
+
+func (h *Handler) GetRepository(w http.ResponseWriter, r *http.Request) {
+	repositoryName := r.URL.Query().Get("repository_name")
+	if repositoryName == "" {
+		respondError(w, http.StatusBadRequest, "BAD_REQUEST", "repository_name is required")
+		return
+	}
+
+	repo, err := h.service.GetRepository(r.Context(), repositoryName)
+	if err != nil {
+		code := parseErrorCode(err)
+		status := getHTTPStatusForError(code)
+		respondError(w, status, code, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"repository": repo})
+}
+
+func (h *Handler) SetCodeowners(w http.ResponseWriter, r *http.Request) {
+	var req models.SetCodeownersRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "BAD_REQUEST", "Invalid request body")
+		return
+	}
+
+	codeowners, err := h.service.SetCodeowners(r.Context(), req)
+	if err != nil {
+		code := parseErrorCode(err)
+		status := getHTTPStatusForError(code)
+		respondError(w, status, code, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"codeowners": codeowners})
+}
+
+func (h *Handler) GetCodeowners(w http.ResponseWriter, r *http.Request) {
+	teamName := r.URL.Query().Get("team_name")
+	if teamName == "" {
+		respondError(w, http.StatusBadRequest, "BAD_REQUEST", "team_name is required")
+		return
+	}
+
+	codeowners, err := h.service.GetCodeowners(r.Context(), teamName)
+	if err != nil {
+		code := parseErrorCode(err)
+		status := getHTTPStatusForError(code)
+		respondError(w, status, code, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"codeowners": codeowners})
+}
+
+func (h *Handler) DeactivateTeamUsers(w http.ResponseWriter, r *http.Request) {
+	var req models.DeactivateUsersRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "BAD_REQUEST", "Invalid request body")
+		return
+	}
+
+	report, err := h.service.DeactivateTeamUsers(r.Context(), req)
+	if err != nil {
+		code := parseErrorCode(err)
+		status := getHTTPStatusForError(code)
+		respondError(w, status, code, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"report": report})
+}
+
 func (h *Handler) SetUserActive(w http.ResponseWriter, r *http.Request) {
 	var req models.SetIsActiveRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -151,7 +296,95 @@ func (h *Handler) SetUserActive(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.service.SetUserActive(r.Context(), req)
+	// ?reassign=true|false переопределяет настройку команды reassign_on_deactivate
+	if value := r.URL.Query().Get("reassign"); value != "" {
+		reassign, err := strconv.ParseBool(value)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "BAD_REQUEST", "reassign must be true or false")
+			return
+		}
+		req.Reassign = &reassign
+	}
+
+	user, reassignments, err := h.service.SetUserActive(r.Context(), req)
+	if err != nil {
+		code := parseErrorCode(err)
+		status := getHTTPStatusForError(code)
+		respondError(w, status, code, err.Error())
+		return
+	}
+
+	resp := map[string]interface{}{"user": user}
+	if reassignments != nil {
+		resp["reassignments"] = reassignments
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) AddOutOfOffice(w http.ResponseWriter, r *http.Request) {
+	var req models.AddOutOfOfficeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "BAD_REQUEST", "Invalid request body")
+		return
+	}
+
+	period, err := h.service.AddOutOfOffice(r.Context(), req)
+	if err != nil {
+		code := parseErrorCode(err)
+		status := getHTTPStatusForError(code)
+		respondError(w, status, code, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, map[string]interface{}{"out_of_office": period})
+}
+
+func (h *Handler) GetOutOfOffice(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		respondError(w, http.StatusBadRequest, "BAD_REQUEST", "user_id is required")
+		return
+	}
+
+	periods, err := h.service.GetOutOfOffice(r.Context(), userID)
+	if err != nil {
+		code := parseErrorCode(err)
+		status := getHTTPStatusForError(code)
+		respondError(w, status, code, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"user_id":       userID,
+		"out_of_office": periods,
+	})
+}
+
+func (h *Handler) DeleteOutOfOffice(w http.ResponseWriter, r *http.Request) {
+	var req models.DeleteOutOfOfficeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "BAD_REQUEST", "Invalid request body")
+		return
+	}
+
+	if err := h.service.DeleteOutOfOffice(r.Context(), req); err != nil {
+		code := parseErrorCode(err)
+		status := getHTTPStatusForError(code)
+		respondError(w, status, code, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"id": req.ID})
+}
+
+func (h *Handler) SetUserMaxOpenReviews(w http.ResponseWriter, r *http.Request) {
+	var req models.SetMaxOpenReviewsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "BAD_REQUEST", "Invalid request body")
+		return
+	}
+
+	user, err := h.service.SetUserMaxOpenReviews(r.Context(), req)
 	if err != nil {
 		code := parseErrorCode(err)
 		status := getHTTPStatusForError(code)
@@ -169,7 +402,7 @@ func (h *Handler) GetUserReviews(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	prs, err := h.service.GetUserReviews(r.Context(), userID)
+	prs, repositories, err := h.service.GetUserReviews(r.Context(), userID)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
 		return
@@ -178,6 +411,7 @@ func (h *Handler) GetUserReviews(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, map[string]interface{}{
 		"user_id":       userID,
 		"pull_requests": prs,
+		"repositories":  repositories,
 	})
 }
 
@@ -205,6 +439,99 @@ func (h *Handler) CreatePR(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusCreated, map[string]interface{}{"pr": pr})
 }
 
+func (h *Handler) PreviewPR(w http.ResponseWriter, r *http.Request) {
+	var req models.CreatePRRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "BAD_REQUEST", "Invalid request body")
+		return
+	}
+
+	preview, err := h.service.PreviewPR(r.Context(), req)
+	if err != nil {
+		code := parseErrorCode(err)
+		status := getHTTPStatusForError(code)
+		respondError(w, status, code, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"preview": preview})
+}
+
+func (h *Handler) GetPR(w http.ResponseWriter, r *http.Request) {
+	prID := r.URL.Query().Get("pull_request_id")
+	if prID == "" {
+		respondError(w, http.StatusBadRequest, "BAD_REQUEST", "pull_request_id is required")
+		return
+	}
+
+	pr, err := h.service.GetPR(r.Context(), prID)
+	if err != nil {
+		code := parseErrorCode(err)
+		status := getHTTPStatusForError(code)
+		respondError(w, status, code, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"pr": pr})
+}
+
+func (h *Handler) SubmitReview(w http.ResponseWriter, r *http.Request) {
+	var req models.SubmitReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "BAD_REQUEST", "Invalid request body")
+		return
+	}
+
+	pr, err := h.service.SubmitReview(r.Context(), req)
+	if err != nil {
+		code := parseErrorCode(err)
+		status := getHTTPStatusForError(code)
+		respondError(w, status, code, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"pr": pr})
+}
+
+func (h *Handler) RerequestReview(w http.ResponseWriter, r *http.Request) {
+	var req models.RerequestReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "BAD_REQUEST", "Invalid request body")
+		return
+	}
+
+	pr, err := h.service.RerequestReview(r.Context(), req)
+	if err != nil {
+		code := parseErrorCode(err)
+		status := getHTTPStatusForError(code)
+		respondError(w, status, code, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"pr": pr})
+}
+
+func (h *Handler) GetReviewRounds(w http.ResponseWriter, r *http.Request) {
+	prID := r.URL.Query().Get("pull_request_id")
+	if prID == "" {
+		respondError(w, http.StatusBadRequest, "BAD_REQUEST", "pull_request_id is required")
+		return
+	}
+
+	rounds, err := h.service.GetReviewRounds(r.Context(), prID)
+	if err != nil {
+		code := parseErrorCode(err)
+		status := getHTTPStatusForError(code)
+		respondError(w, status, code, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"pull_request_id": prID,
+		"rounds":          rounds,
+	})
+}
+
 func (h *Handler) MergePR(w http.ResponseWriter, r *http.Request) {
 	var req models.MergePRRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -223,6 +550,60 @@ func (h *Handler) MergePR(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, map[string]interface{}{"pr": pr})
 }
 
+func (h *Handler) ClosePR(w http.ResponseWriter, r *http.Request) {
+	var req models.PRStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "BAD_REQUEST", "Invalid request body")
+		return
+	}
+
+	pr, err := h.service.ClosePR(r.Context(), req)
+	if err != nil {
+		code := parseErrorCode(err)
+		status := getHTTPStatusForError(code)
+		respondError(w, status, code, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"pr": pr})
+}
+
+func (h *Handler) ReopenPR(w http.ResponseWriter, r *http.Request) {
+	var req models.PRStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "BAD_REQUEST", "Invalid request body")
+		return
+	}
+
+	pr, err := h.service.ReopenPR(r.Context(), req)
+	if err != nil {
+		code := parseErrorCode(err)
+		status := getHTTPStatusForError(code)
+		respondError(w, status, code, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"pr": pr})
+}
+
+func (h *Handler) MarkPRReady(w http.ResponseWriter, r *http.Request) {
+	var req models.PRStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "BAD_REQUEST", "Invalid request body")
+		return
+	}
+
+	pr, err := h.service.MarkPRReady(r.Context(), req)
+	if err != nil {
+		code := parseErrorCode(err)
+		status := getHTTPStatusForError(code)
+		respondError(w, status, code, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"pr": pr})
+}
+
 func (h *Handler) ReassignReviewer(w http.ResponseWriter, r *http.Request) {
 	var req models.ReassignRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -244,6 +625,105 @@ func (h *Handler) ReassignReviewer(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (h *Handler) DeclineReview(w http.ResponseWriter, r *http.Request) {
+	var req models.DeclineRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "BAD_REQUEST", "Invalid request body")
+		return
+	}
+
+	pr, decline, err := h.service.DeclineReview(r.Context(), req)
+	if err != nil {
+		code := parseErrorCode(err)
+		status := getHTTPStatusForError(code)
+		respondError(w, status, code, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"pr":      pr,
+		"decline": decline,
+	})
+}
+
+func (h *Handler) AddReviewer(w http.ResponseWriter, r *http.Request) {
+	var req models.ReviewerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "BAD_REQUEST", "Invalid request body")
+		return
+	}
+
+	pr, err := h.service.AddReviewer(r.Context(), req)
+	if err != nil {
+		code := parseErrorCode(err)
+		status := getHTTPStatusForError(code)
+		respondError(w, status, code, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"pr": pr})
+}
+
+func (h *Handler) RemoveReviewer(w http.ResponseWriter, r *http.Request) {
+	var req models.ReviewerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "BAD_REQUEST", "Invalid request body")
+		return
+	}
+
+	pr, err := h.service.RemoveReviewer(r.Context(), req)
+	if err != nil {
+		code := parseErrorCode(err)
+		status := getHTTPStatusForError(code)
+		respondError(w, status, code, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"pr": pr})
+}
+
+func (h *Handler) GetAssignmentExplanations(w http.ResponseWriter, r *http.Request) {
+	prID := r.URL.Query().Get("pull_request_id")
+	if prID == "" {
+		respondError(w, http.StatusBadRequest, "BAD_REQUEST", "pull_request_id is required")
+		return
+	}
+
+	explanations, err := h.service.GetAssignmentExplanations(r.Context(), prID)
+	if err != nil {
+		code := parseErrorCode(err)
+		status := getHTTPStatusForError(code)
+		respondError(w, status, code, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"pull_request_id": prID,
+		"assignments":     explanations,
+	})
+}
+
+func (h *Handler) GetSLAEvents(w http.ResponseWriter, r *http.Request) {
+	prID := r.URL.Query().Get("pull_request_id")
+	if prID == "" {
+		respondError(w, http.StatusBadRequest, "BAD_REQUEST", "pull_request_id is required")
+		return
+	}
+
+	events, err := h.service.GetSLAEvents(r.Context(), prID)
+	if err != nil {
+		code := parseErrorCode(err)
+		status := getHTTPStatusForError(code)
+		respondError(w, status, code, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"pull_request_id": prID,
+		"events":          events,
+	})
+}
+
 func (h *Handler) GetStats(w http.ResponseWriter, r *http.Request) {
 	stats, err := h.service.GetStats(r.Context())
 	if err != nil {
@@ -3,31 +3,282 @@ package models
 import "time"
 
 type User struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
-	TeamName string `json:"team_name"`
-	IsActive bool   `json:"is_active"`
+	UserID   string   `json:"user_id"`
+	Username string   `json:"username"`
+	TeamName string   `json:"team_name"`
+	IsActive bool     `json:"is_active"`
+	Tags     []string `json:"tags"`
+	Level    string   `json:"level"`
+	Timezone string   `json:"timezone"`
+	// nil - расписание не задано, пользователь доступен в любое время
+	WorkingHours *WorkingHours `json:"working_hours,omitempty"`
+	// лимит одновременно открытых ревью, nil - без ограничения
+	MaxOpenReviews *int `json:"max_open_reviews"`
 }
 
 type TeamMember struct {
 	UserID   string `json:"user_id"`
 	Username string `json:"username"`
 	IsActive bool   `json:"is_active"`
+	// экспертиза участника (go, postgres, frontend...), в запросе nil - не менять
+	Tags []string `json:"tags"`
+	// уровень участника, в запросе пустое значение - не менять (для новых - middle)
+	Level string `json:"level"`
+	// часовой пояс IANA (Europe/Moscow), в запросе пустое значение - не менять (для новых - UTC)
+	Timezone string `json:"timezone"`
+	// в запросе nil - не менять
+	WorkingHours *WorkingHours `json:"working_hours,omitempty"`
+}
+
+// рабочее время участника в его часовом поясе
+type WorkingHours struct {
+	// начало и конец рабочего дня в формате 15:04, конец раньше начала - ночная смена
+	Start string `json:"start"`
+	End   string `json:"end"`
+	// рабочие дни недели: 1 - понедельник ... 7 - воскресенье, по умолчанию пн-пт
+	Days []int `json:"days"`
+}
+
+// ближайшее (или текущее) рабочее окно ревьювера
+type WorkingWindow struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// настройки назначения ревьюверов, задаются отдельно для каждой команды
+type TeamSettings struct {
+	AssignmentStrategy string `json:"assignment_strategy"`
+	ReviewersPerPR     int    `json:"reviewers_per_pr"`
+	// среди ревьюверов PR должен быть хотя бы один senior или lead
+	RequireSenior bool `json:"require_senior"`
+	// команды-партнеры в порядке приоритета: из них добираются ревьюверы,
+	// если в команде не хватает активных участников
+	FallbackTeams []string `json:"fallback_teams"`
+	// сколько последних PR автора учитывается: их ревьюверы выбираются в последнюю очередь (0 - не учитывать)
+	PairingLookback int `json:"pairing_lookback"`
+	// при деактивации участника его открытые ревью переназначаются автоматически
+	ReassignOnDeactivate bool `json:"reassign_on_deactivate"`
+	// SLA ревью в рабочих часах ревьювера (0 - не отслеживается) и сколько рабочих часов
+	// после просрочки ждать перед автоматическим переназначением
+	ReviewSLAHours int `json:"review_sla_hours"`
+	SLAGraceHours  int `json:"sla_grace_hours"`
+	// сколько ревьюверов должны одобрить PR перед merge (0 - одобрения не требуются)
+	RequiredApprovals int `json:"required_approvals"`
+	// правила, меняющие настройки назначения для PR по размеру, приоритету и меткам
+	AssignmentRules []AssignmentRule `json:"assignment_rules"`
+}
+
+// AssignmentRule применяется к новому PR, если выполнены все заданные условия, и заменяет
+// заданные в нем настройки команды. Подходящие правила применяются по порядку, более позднее
+// переопределяет более раннее
+type AssignmentRule struct {
+	// условия: строк изменено не меньше (добавлено + удалено), файлов не меньше, приоритет, метка PR
+	MinLines *int   `json:"min_lines,omitempty"`
+	MinFiles *int   `json:"min_files,omitempty"`
+	Priority string `json:"priority,omitempty"`
+	Label    string `json:"label,omitempty"`
+	// действия
+	ReviewersPerPR     *int   `json:"reviewers_per_pr,omitempty"`
+	AssignmentStrategy string `json:"assignment_strategy,omitempty"`
+	RequireSenior      *bool  `json:"require_senior,omitempty"`
+}
+
+// репозиторий, которым владеют одна или несколько команд
+type Repository struct {
+	RepositoryName string   `json:"repository_name"`
+	Teams          []string `json:"teams"`
+	RepositorySettings
+}
+
+// настройки репозитория заменяют настройки команды автора для PR этого репозитория,
+// не заданная настройка берется из команды
+type RepositorySettings struct {
+	ReviewersPerPR     *int   `json:"reviewers_per_pr,omitempty"`
+	AssignmentStrategy string `json:"assignment_strategy,omitempty"`
+	RequireSenior      *bool  `json:"require_senior,omitempty"`
+	RequiredApprovals  *int   `json:"required_approvals,omitempty"`
 }
 
 type Team struct {
-	TeamName string       `json:"team_name"`
-	Members  []TeamMember `json:"members"`
+	TeamName string `json:"team_name"`
+	TeamSettings
+	Members []TeamMember `json:"members"`
 }
 
 type PullRequest struct {
-	PullRequestID     string     `json:"pull_request_id"`
-	PullRequestName   string     `json:"pull_request_name"`
-	AuthorID          string     `json:"author_id"`
-	Status            string     `json:"status"`
-	AssignedReviewers []string   `json:"assigned_reviewers"`
-	CreatedAt         *time.Time `json:"createdAt,omitempty"`
-	MergedAt          *time.Time `json:"mergedAt,omitempty"`
+	PullRequestID string `json:"pull_request_id"`
+	// репозиторий и номер PR в нем; у PR репозитория pull_request_id имеет вид "repository#number"
+	Repository        string   `json:"repository,omitempty"`
+	Number            int      `json:"number,omitempty"`
+	PullRequestName   string   `json:"pull_request_name"`
+	AuthorID          string   `json:"author_id"`
+	Status            string   `json:"status"`
+	AssignedReviewers []string `json:"assigned_reviewers"`
+	// ревьюверы в порядке назначения с указанием команды-партнера
+	Reviewers    []ReviewerInfo `json:"reviewers,omitempty"`
+	RequiredTags []string       `json:"required_tags,omitempty"`
+	// измененные файлы; сохраняются, чтобы назначить ревьюверов черновику, когда он будет готов
+	ChangedFiles []string `json:"changed_files,omitempty"`
+	// правило CODEOWNERS, по которому назначен ревьювер-владелец
+	OwnershipMatch *OwnershipMatch `json:"ownership_match,omitempty"`
+	CreatedAt      *time.Time      `json:"createdAt,omitempty"`
+	MergedAt       *time.Time      `json:"mergedAt,omitempty"`
+	ClosedAt       *time.Time      `json:"closedAt,omitempty"`
+	// текущий раунд ревью, 0 - черновик, ревью еще не запрашивалось
+	ReviewRound int `json:"review_round,omitempty"`
+	PRMetadata
+}
+
+// размер, приоритет и метки PR
+type PRMetadata struct {
+	LinesAdded   int      `json:"lines_added,omitempty"`
+	LinesRemoved int      `json:"lines_removed,omitempty"`
+	FilesChanged int      `json:"files_changed,omitempty"`
+	Priority     string   `json:"priority,omitempty"`
+	Labels       []string `json:"labels,omitempty"`
+}
+
+// ReviewerInfo - ревьювер PR и команда, из которой он назначен
+type ReviewerInfo struct {
+	UserID string `json:"user_id"`
+	// команда-партнер, если ревьювер взят не из команды автора
+	FallbackTeam string `json:"fallback_team,omitempty"`
+	// текущее или ближайшее рабочее окно, если у ревьювера задано расписание
+	NextWorkingWindow *WorkingWindow `json:"next_working_window,omitempty"`
+	// когда ревью было помечено просроченным по SLA команды
+	OverdueAt *time.Time `json:"overdue_at,omitempty"`
+	// итог ревью, пусто - ревьювер еще не ответил
+	Verdict   string     `json:"verdict,omitempty"`
+	VerdictAt *time.Time `json:"verdict_at,omitempty"`
+}
+
+// правило файла владельцев команды
+type CodeownersRule struct {
+	Pattern string   `json:"pattern"`
+	Owners  []string `json:"owners"`
+	Line    int      `json:"line"`
+}
+
+type TeamCodeowners struct {
+	TeamName string           `json:"team_name"`
+	Content  string           `json:"content"`
+	Rules    []CodeownersRule `json:"rules"`
+}
+
+// совпадение измененного файла PR с правилом CODEOWNERS
+type OwnershipMatch struct {
+	Pattern string   `json:"pattern"`
+	Line    int      `json:"line"`
+	Path    string   `json:"path"`
+	Owners  []string `json:"owners"`
+	// владелец, назначенный ревьювером
+	Owner string `json:"owner,omitempty"`
+}
+
+// период отсутствия пользователя, пока он идет, пользователь не назначается ревьювером
+type OutOfOffice struct {
+	ID       int64     `json:"id"`
+	UserID   string    `json:"user_id"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+	Reason   string    `json:"reason"`
+}
+
+// объяснение одного назначения ревьюверов на PR
+type AssignmentExplanation struct {
+	ID            int64  `json:"id"`
+	PullRequestID string `json:"pull_request_id"`
+	// initial - при создании PR, reassign - при переназначении
+	Kind     string `json:"kind"`
+	Strategy string `json:"strategy"`
+	// seed генератора случайных чисел, использованного стратегией
+	Seed int64 `json:"seed"`
+	// все кандидаты, рассмотренные при выборе
+	CandidatePool []string            `json:"candidate_pool"`
+	Excluded      []ExcludedCandidate `json:"excluded"`
+	Selected      []string            `json:"selected"`
+	CreatedAt     time.Time           `json:"created_at"`
+}
+
+// результат пробного назначения ревьюверов, ничего не сохраняется
+type AssignmentPreview struct {
+	AuthorID       string              `json:"author_id"`
+	Reviewers      []ReviewerInfo      `json:"reviewers"`
+	RequiredTags   []string            `json:"required_tags,omitempty"`
+	OwnershipMatch *OwnershipMatch     `json:"ownership_match,omitempty"`
+	Strategy       string              `json:"strategy"`
+	CandidatePool  []string            `json:"candidate_pool"`
+	Excluded       []ExcludedCandidate `json:"excluded"`
+}
+
+type ExcludedCandidate struct {
+	UserID string `json:"user_id"`
+	Reason string `json:"reason"`
+}
+
+type ReviewDecline struct {
+	ID            int64  `json:"id"`
+	PullRequestID string `json:"pull_request_id"`
+	UserID        string `json:"user_id"`
+	Reason        string `json:"reason"`
+	// пусто - замены не нашлось
+	ReplacedBy string    `json:"replaced_by,omitempty"`
+	DeclinedAt time.Time `json:"declined_at"`
+}
+
+// назначение ревьювера, для которого отслеживается SLA команды автора PR
+type ReviewAssignment struct {
+	OrgID         string
+	PullRequestID string
+	UserID        string
+	AssignedAt    time.Time
+	// nil - ревью еще не помечено просроченным
+	OverdueAt      *time.Time
+	ReviewSLAHours int
+	SLAGraceHours  int
+}
+
+type SLAEvent struct {
+	ID            int64  `json:"id"`
+	PullRequestID string `json:"pull_request_id"`
+	UserID        string `json:"user_id"`
+	Kind          string `json:"kind"`
+	// только для reassigned
+	ReplacedBy string    `json:"replaced_by,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// раунд ревью PR с итогами ревьюверов в порядке их отправки
+type ReviewRound struct {
+	Round     int             `json:"round"`
+	StartedAt time.Time       `json:"started_at"`
+	Verdicts  []ReviewVerdict `json:"verdicts"`
+}
+
+type ReviewVerdict struct {
+	UserID  string `json:"user_id"`
+	Verdict string `json:"verdict"`
+	// когда ревью было запрошено у ревьювера в этом раунде
+	RequestedAt time.Time `json:"requested_at"`
+	SubmittedAt time.Time `json:"submitted_at"`
+}
+
+// результат деактивации пользователей: замены на каждом затронутом открытом PR
+type DeactivationReport struct {
+	TeamName         string           `json:"team_name"`
+	DeactivatedUsers []string         `json:"deactivated_users"`
+	PullRequests     []PRReassignment `json:"pull_requests"`
+}
+
+type PRReassignment struct {
+	PullRequestID string                `json:"pull_request_id"`
+	Replacements  []ReviewerReplacement `json:"replacements"`
+}
+
+type ReviewerReplacement struct {
+	OldUserID string `json:"old_user_id"`
+	// пусто - замены не нашлось, место ревьювера осталось свободным
+	NewUserID string `json:"new_user_id,omitempty"`
 }
 
 type PullRequestShort struct {
@@ -35,20 +286,123 @@ type PullRequestShort struct {
 	PullRequestName string `json:"pull_request_name"`
 	AuthorID        string `json:"author_id"`
 	Status          string `json:"status"`
+	Repository      string `json:"repository,omitempty"`
+}
+
+// PR, назначенные пользователю, в одном репозитории; пустое имя - PR без репозитория
+type RepositoryReviews struct {
+	Repository   string             `json:"repository"`
+	PullRequests []PullRequestShort `json:"pull_requests"`
 }
 
 const (
-	ErrCodeTeamExists  = "TEAM_EXISTS"
-	ErrCodePRExists    = "PR_EXISTS"
-	ErrCodePRMerged    = "PR_MERGED"
+	ErrCodeTeamExists = "TEAM_EXISTS"
+	ErrCodeRepoExists = "REPOSITORY_EXISTS"
+	ErrCodePRExists   = "PR_EXISTS"
+	ErrCodePRMerged   = "PR_MERGED"
+	// PR закрыт без merge, действие доступно после reopen
+	ErrCodePRClosed = "PR_CLOSED"
+	// PR - черновик, действие доступно после перевода в ready
+	ErrCodePRDraft     = "PR_DRAFT"
 	ErrCodeNotAssigned = "NOT_ASSIGNED"
 	ErrCodeNoCandidate = "NO_CANDIDATE"
-	ErrCodeNotFound    = "NOT_FOUND"
+	// кандидаты есть, но у всех достигнут лимит открытых ревью
+	ErrCodeNoCapacity = "NO_CAPACITY"
+	// у PR меньше одобрений, чем требует команда автора
+	ErrCodeNotApproved = "NOT_APPROVED"
+	// пользователь уже назначен ревьювером PR
+	ErrCodeAlreadyAssigned = "ALREADY_ASSIGNED"
+	// пользователя нельзя назначить ревьювером: он неактивен или автор PR
+	ErrCodeInvalidReviewer = "INVALID_REVIEWER"
+	ErrCodeNotFound        = "NOT_FOUND"
+	ErrCodeBadRequest      = "BAD_REQUEST"
+	// API-токен не передан (при AUTH_REQUIRED) или неизвестен
+	ErrCodeUnauthorized = "UNAUTHORIZED"
 )
 
 const (
 	StatusOpen   = "OPEN"
 	StatusMerged = "MERGED"
+	// закрыт без merge, ревьюверы сохраняются до reopen
+	StatusClosed = "CLOSED"
+	// черновик, ревьюверы назначаются при переводе в ready
+	StatusDraft = "DRAFT"
+)
+
+// приоритеты PR
+const (
+	PriorityLow    = "low"
+	PriorityNormal = "normal"
+	PriorityUrgent = "urgent"
+)
+
+// итоги ревью
+const (
+	VerdictApproved         = "APPROVED"
+	VerdictChangesRequested = "CHANGES_REQUESTED"
+	VerdictCommented        = "COMMENTED"
+)
+
+// виды назначений в объяснениях
+const (
+	AssignmentInitial  = "initial"
+	AssignmentReassign = "reassign"
+	// ручное изменение ревьюверов, в объяснении это же значение указывается как стратегия
+	AssignmentManual = "manual"
+	// замена ревьювера, отказавшегося от PR
+	AssignmentDecline = "decline"
+	// переназначение просроченного по SLA ревью
+	AssignmentSLA = "sla"
+)
+
+// события SLA ревью
+const (
+	SLAEventOverdue    = "overdue"
+	SLAEventReassigned = "reassigned"
+)
+
+// причины исключения кандидата из выбора
+const (
+	ExclusionAuthor          = "author"
+	ExclusionInactive        = "inactive"
+	ExclusionOutOfOffice     = "out_of_office"
+	ExclusionAtCapacity      = "at_capacity"
+	ExclusionAlreadyAssigned = "already_assigned"
+	ExclusionReplaced        = "replaced"
+	ExclusionNotSenior       = "not_senior"
+	ExclusionRemoved         = "removed"
+)
+
+// стратегии выбора ревьюверов
+const (
+	StrategyRandom      = "random"
+	StrategyLeastLoaded = "least_loaded"
+	StrategyRoundRobin  = "round_robin"
+	StrategyWeighted    = "weighted"
+
+	DefaultStrategy = StrategyLeastLoaded
+)
+
+// уровни участников
+const (
+	LevelJunior = "junior"
+	LevelMiddle = "middle"
+	LevelSenior = "senior"
+	LevelLead   = "lead"
+)
+
+const (
+	DefaultReviewersPerPR = 2
+	// верхняя граница числа ревьюверов на одном PR
+	MaxReviewersPerPR = 10
+	// верхняя граница pairing_lookback
+	MaxPairingLookback = 20
+	// ожидание после просрочки SLA по умолчанию - один рабочий день
+	DefaultSLAGraceHours = 8
+	// верхняя граница review_sla_hours и sla_grace_hours
+	MaxSLAHours = 720
+	// сколько правил назначения может быть у команды
+	MaxAssignmentRules = 20
 )
 
 type ErrorResponse struct {
@@ -61,26 +415,117 @@ type ErrorResponse struct {
 type CreateTeamRequest struct {
 	TeamName string       `json:"team_name"`
 	Members  []TeamMember `json:"members"`
+	// пустое значение - стратегия по умолчанию при создании и без изменений при обновлении
+	AssignmentStrategy string `json:"assignment_strategy,omitempty"`
+	ReviewersPerPR     *int   `json:"reviewers_per_pr,omitempty"`
+	RequireSenior      *bool  `json:"require_senior,omitempty"`
+	// nil - не менять, пустой список - убрать команды-партнеры
+	FallbackTeams        []string `json:"fallback_teams,omitempty"`
+	PairingLookback      *int     `json:"pairing_lookback,omitempty"`
+	ReassignOnDeactivate *bool    `json:"reassign_on_deactivate,omitempty"`
+	ReviewSLAHours       *int     `json:"review_sla_hours,omitempty"`
+	SLAGraceHours        *int     `json:"sla_grace_hours,omitempty"`
+	RequiredApprovals    *int     `json:"required_approvals,omitempty"`
+	// nil - не менять, пустой список - убрать правила
+	AssignmentRules []AssignmentRule `json:"assignment_rules,omitempty"`
 }
 
 type SetIsActiveRequest struct {
 	UserID   string `json:"user_id"`
 	IsActive bool   `json:"is_active"`
+	// параметр запроса reassign: переназначить открытые ревью при деактивации,
+	// nil - как задано в настройке команды reassign_on_deactivate
+	Reassign *bool `json:"-"`
+}
+
+type DeactivateUsersRequest struct {
+	TeamName string   `json:"team_name"`
+	UserIDs  []string `json:"user_ids"`
+}
+
+type SetMaxOpenReviewsRequest struct {
+	UserID string `json:"user_id"`
+	// null - снять ограничение
+	MaxOpenReviews *int `json:"max_open_reviews"`
+}
+
+type AddOutOfOfficeRequest struct {
+	UserID   string    `json:"user_id"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+	Reason   string    `json:"reason"`
+}
+
+type DeleteOutOfOfficeRequest struct {
+	ID int64 `json:"id"`
 }
 
 type CreatePRRequest struct {
-	PullRequestID   string `json:"pull_request_id"`
+	PullRequestID string `json:"pull_request_id"`
+	// PR репозитория задается номером, pull_request_id тогда можно не передавать
+	Repository      string `json:"repository,omitempty"`
+	Number          int    `json:"number,omitempty"`
 	PullRequestName string `json:"pull_request_name"`
 	AuthorID        string `json:"author_id"`
+	// теги, которые должны быть у ревьюверов; при отсутствии подходящих назначаются любые
+	RequiredTags []string `json:"required_tags,omitempty"`
+	// измененные файлы, по ним выбирается владелец кода из CODEOWNERS команды
+	ChangedFiles []string `json:"changed_files,omitempty"`
+	// создать черновик без ревьюверов
+	Draft bool `json:"draft,omitempty"`
+	// если files_changed не передан, используется число changed_files; приоритет по умолчанию normal
+	PRMetadata
+}
+
+type CreateRepositoryRequest struct {
+	RepositoryName string `json:"repository_name"`
+	// при обновлении nil - не менять владельцев
+	Teams []string `json:"teams"`
+	RepositorySettings
+}
+
+type SetCodeownersRequest struct {
+	TeamName string `json:"team_name"`
+	Content  string `json:"content"`
 }
 
 type MergePRRequest struct {
 	PullRequestID string `json:"pull_request_id"`
 }
 
+type SubmitReviewRequest struct {
+	PullRequestID string `json:"pull_request_id"`
+	UserID        string `json:"user_id"`
+	Verdict       string `json:"verdict"`
+}
+
+type RerequestReviewRequest struct {
+	PullRequestID string `json:"pull_request_id"`
+}
+
+// запрос на смену статуса PR: close, reopen, ready
+type PRStatusRequest struct {
+	PullRequestID string `json:"pull_request_id"`
+}
+
 type ReassignRequest struct {
 	PullRequestID string `json:"pull_request_id"`
 	OldUserID     string `json:"old_user_id"`
+	// конкретный новый ревьювер, без него замена выбирается стратегией команды
+	NewUserID string `json:"new_user_id,omitempty"`
+}
+
+// отказ ревьювера от PR
+type DeclineRequest struct {
+	PullRequestID string `json:"pull_request_id"`
+	UserID        string `json:"user_id"`
+	Reason        string `json:"reason"`
+}
+
+// запрос на ручное добавление или снятие ревьювера
+type ReviewerRequest struct {
+	PullRequestID string `json:"pull_request_id"`
+	UserID        string `json:"user_id"`
 }
 
 type ReassignResponse struct {
@@ -96,6 +541,15 @@ type UserStats struct {
 	TotalPRsAuthored int    `json:"total_prs_authored"`
 	TotalReviews     int    `json:"total_reviews_assigned"`
 	ActiveReviews    int    `json:"active_reviews"`
+	// лимит открытых ревью и сколько еще ревью можно назначить, null - без ограничения
+	MaxOpenReviews    *int `json:"max_open_reviews"`
+	RemainingCapacity *int `json:"remaining_capacity"`
+	// отказы от назначенных ревью и их доля среди всех назначений пользователя
+	TotalDeclines int     `json:"total_declines"`
+	DeclineRate   float64 `json:"decline_rate"`
+	// раунды ревью, в которых пользователь отправил итог, и среднее время до первого итога в раунде
+	ReviewRounds         int     `json:"review_rounds"`
+	AvgRoundLatencyHours float64 `json:"avg_round_latency_hours"`
 }
 
 type StatsResponse struct {
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"pr-reviewer-service/internal/models"
+	"pr-reviewer-service/internal/tenant"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// SetCodeowners сохраняет файл владельцев команды, заменяя предыдущий
+func (r *Repository) SetCodeowners(ctx context.Context, teamName, content string) error {
+	result, err := r.conn(ctx).Exec(ctx, `
+		INSERT INTO team_codeowners (org_id, team_name, content)
+		SELECT org_id, team_name, $2 FROM teams WHERE team_name = $1 AND org_id = $3
+		ON CONFLICT (org_id, team_name) DO UPDATE
+		SET content = EXCLUDED.content,
+		    updated_at = CURRENT_TIMESTAMP
+	`, teamName, content, tenant.OrgFromContext(ctx))
+	if err != nil {
+		return err
+	}
+
+	// команды не существует - вставлять нечего
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *Repository) GetCodeowners(ctx context.Context, teamName string) (string, error) {
+	var content string
+	err := r.conn(ctx).QueryRow(ctx, `
+		SELECT content
+		FROM team_codeowners
+		WHERE team_name = $1 AND org_id = $2
+	`, teamName, tenant.OrgFromContext(ctx)).Scan(&content)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrNotFound
+		}
+		return "", err
+	}
+
+	return content, nil
+}
+
+// активные пользователи из списка, неизвестные, неактивные и отсутствующие сейчас пропускаются
+func (r *Repository) GetActiveUsers(ctx context.Context, userIDs []string) ([]models.User, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT `+userColumns+`
+		FROM users
+		WHERE user_id = ANY($1) AND org_id = $2 AND is_active = true AND `+notOutOfOffice+`
+	`, userIDs, tenant.OrgFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *user)
+	}
+
+	return users, nil
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+
+	"pr-reviewer-service/internal/models"
+	"pr-reviewer-service/internal/tenant"
+)
+
+// DeactivateTeamUsers деактивирует пользователей команды из списка и возвращает их,
+// пользователи других команд и неизвестные пропускаются
+func (r *Repository) DeactivateTeamUsers(ctx context.Context, teamName string, userIDs []string) ([]models.User, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+		UPDATE users
+		SET is_active = false, updated_at = CURRENT_TIMESTAMP
+		WHERE team_name = $1 AND user_id = ANY($2) AND org_id = $3
+		RETURNING `+userColumns, teamName, userIDs, tenant.OrgFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *user)
+	}
+
+	return users, nil
+}
+
+// GetOpenPRsReviewedBy возвращает открытые PR, где ревьювером назначен кто-то из пользователей,
+// вместе со всеми их ревьюверами. Блокирует строки PR до конца транзакции
+func (r *Repository) GetOpenPRsReviewedBy(ctx context.Context, userIDs []string) ([]models.PullRequest, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+		WITH affected AS (
+			SELECT pr.org_id, pr.pull_request_id, pr.pull_request_name, pr.author_id, pr.status, pr.required_tags
+			FROM pull_requests pr
+			WHERE pr.status = 'OPEN' AND pr.org_id = $2 AND EXISTS (
+				SELECT 1 FROM pull_request_reviewers prr
+				WHERE prr.org_id = pr.org_id AND prr.pull_request_id = pr.pull_request_id AND prr.user_id = ANY($1)
+			)
+			FOR UPDATE
+		)
+		SELECT a.pull_request_id, a.pull_request_name, a.author_id, a.status, a.required_tags,
+		       prr.user_id, COALESCE(prr.fallback_team, '')
+		FROM affected a
+		JOIN pull_request_reviewers prr ON prr.org_id = a.org_id AND prr.pull_request_id = a.pull_request_id
+		ORDER BY a.pull_request_id, prr.assigned_at, prr.user_id
+	`, userIDs, tenant.OrgFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prs := []models.PullRequest{}
+	for rows.Next() {
+		var pr models.PullRequest
+		var reviewer models.ReviewerInfo
+		err := rows.Scan(
+			&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status, &pr.RequiredTags,
+			&reviewer.UserID, &reviewer.FallbackTeam,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		// строки одного PR идут подряд
+		if len(prs) == 0 || prs[len(prs)-1].PullRequestID != pr.PullRequestID {
+			pr.AssignedReviewers = []string{}
+			pr.Reviewers = []models.ReviewerInfo{}
+			prs = append(prs, pr)
+		}
+		last := &prs[len(prs)-1]
+		last.AssignedReviewers = append(last.AssignedReviewers, reviewer.UserID)
+		last.Reviewers = append(last.Reviewers, reviewer)
+	}
+
+	return prs, nil
+}
+
+// ReplaceReviewers снимает пользователей со всех переданных PR и назначает новых ревьюверов
+// (pull_request_id -> ревьюверы) двумя запросами независимо от числа PR
+func (r *Repository) ReplaceReviewers(
+	ctx context.Context, prIDs, removedUserIDs []string, added map[string][]models.ReviewerInfo,
+) error {
+	_, err := r.conn(ctx).Exec(ctx, `
+		DELETE FROM pull_request_reviewers
+		WHERE pull_request_id = ANY($1) AND user_id = ANY($2) AND org_id = $3
+	`, prIDs, removedUserIDs, tenant.OrgFromContext(ctx))
+	if err != nil {
+		return err
+	}
+
+	var addedPRs, addedUsers, fallbackTeams []string
+	for prID, reviewers := range added {
+		for _, reviewer := range reviewers {
+			addedPRs = append(addedPRs, prID)
+			addedUsers = append(addedUsers, reviewer.UserID)
+			fallbackTeams = append(fallbackTeams, reviewer.FallbackTeam)
+		}
+	}
+	if len(addedPRs) == 0 {
+		return nil
+	}
+
+	_, err = r.conn(ctx).Exec(ctx, `
+		INSERT INTO pull_request_reviewers (org_id, pull_request_id, user_id, fallback_team)
+		SELECT $4, pr_id, user_id, NULLIF(fallback_team, '')
+		FROM unnest($1::text[], $2::text[], $3::text[]) AS t(pr_id, user_id, fallback_team)
+	`, addedPRs, addedUsers, fallbackTeams, tenant.OrgFromContext(ctx))
+	return err
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+
+	"pr-reviewer-service/internal/models"
+	"pr-reviewer-service/internal/tenant"
+)
+
+// AddReviewDecline сохраняет отказ ревьювера, заполняя ID и DeclinedAt
+func (r *Repository) AddReviewDecline(ctx context.Context, d *models.ReviewDecline) error {
+	return r.conn(ctx).QueryRow(ctx, `
+		INSERT INTO review_declines (pull_request_id, user_id, reason, replaced_by, org_id)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5)
+		RETURNING id, declined_at
+	`, d.PullRequestID, d.UserID, d.Reason, d.ReplacedBy, tenant.OrgFromContext(ctx)).Scan(&d.ID, &d.DeclinedAt)
+}
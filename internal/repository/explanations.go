@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+
+	"pr-reviewer-service/internal/models"
+	"pr-reviewer-service/internal/tenant"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// AddAssignmentExplanation сохраняет объяснение назначения, заполняя ID и CreatedAt
+func (r *Repository) AddAssignmentExplanation(ctx context.Context, e *models.AssignmentExplanation) error {
+	return r.conn(ctx).QueryRow(ctx, `
+		INSERT INTO assignment_explanations (pull_request_id, kind, strategy, seed, candidate_pool, excluded, selected, org_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`, e.PullRequestID, e.Kind, e.Strategy, e.Seed, e.CandidatePool, e.Excluded, e.Selected,
+		tenant.OrgFromContext(ctx)).Scan(&e.ID, &e.CreatedAt)
+}
+
+// AddAssignmentExplanations сохраняет пачку объяснений за один обмен с базой, заполняя ID и CreatedAt
+func (r *Repository) AddAssignmentExplanations(ctx context.Context, explanations []*models.AssignmentExplanation) error {
+	if len(explanations) == 0 {
+		return nil
+	}
+
+	orgID := tenant.OrgFromContext(ctx)
+	batch := &pgx.Batch{}
+	for _, e := range explanations {
+		batch.Queue(`
+			INSERT INTO assignment_explanations (pull_request_id, kind, strategy, seed, candidate_pool, excluded, selected, org_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING id, created_at
+		`, e.PullRequestID, e.Kind, e.Strategy, e.Seed, e.CandidatePool, e.Excluded, e.Selected, orgID).QueryRow(func(row pgx.Row) error {
+			return row.Scan(&e.ID, &e.CreatedAt)
+		})
+	}
+
+	return r.conn(ctx).SendBatch(ctx, batch).Close()
+}
+
+// объяснения всех назначений PR в порядке их выполнения
+func (r *Repository) GetAssignmentExplanations(ctx context.Context, prID string) ([]models.AssignmentExplanation, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT id, pull_request_id, kind, strategy, seed, candidate_pool, excluded, selected, created_at
+		FROM assignment_explanations
+		WHERE pull_request_id = $1 AND org_id = $2
+		ORDER BY created_at, id
+	`, prID, tenant.OrgFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	explanations := []models.AssignmentExplanation{}
+	for rows.Next() {
+		var e models.AssignmentExplanation
+		err := rows.Scan(
+			&e.ID, &e.PullRequestID, &e.Kind, &e.Strategy, &e.Seed,
+			&e.CandidatePool, &e.Excluded, &e.Selected, &e.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		explanations = append(explanations, e)
+	}
+
+	return explanations, nil
+}
+
+// недоступные участники команд с причиной: неактивные и находящиеся в периоде отсутствия
+func (r *Repository) GetUnavailableMembers(ctx context.Context, teamNames []string) (map[string]string, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT user_id, CASE WHEN is_active THEN $2 ELSE $3 END
+		FROM users
+		WHERE team_name = ANY($1) AND org_id = $4 AND (NOT is_active OR NOT `+notOutOfOffice+`)
+	`, teamNames, models.ExclusionOutOfOffice, models.ExclusionInactive, tenant.OrgFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	unavailable := make(map[string]string)
+	for rows.Next() {
+		var userID, reason string
+		if err := rows.Scan(&userID, &reason); err != nil {
+			return nil, err
+		}
+		unavailable[userID] = reason
+	}
+
+	return unavailable, nil
+}
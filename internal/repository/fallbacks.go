@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"pr-reviewer-service/internal/models"
+	"pr-reviewer-service/internal/tenant"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// заменяет команды-партнеры команды, порядок в списке задает приоритет; партнер - команда той же организации
+func setTeamFallbacks(ctx context.Context, tx pgx.Tx, teamName string, fallbackTeams []string) error {
+	_, err := tx.Exec(ctx, "DELETE FROM team_fallbacks WHERE team_name = $1 AND org_id = $2", teamName, tenant.OrgFromContext(ctx))
+	if err != nil {
+		return err
+	}
+
+	for i, fallbackTeam := range fallbackTeams {
+		result, err := tx.Exec(ctx, `
+			INSERT INTO team_fallbacks (org_id, team_name, fallback_team, priority)
+			SELECT org_id, $1, team_name, $3 FROM teams WHERE team_name = $2 AND org_id = $4
+		`, teamName, fallbackTeam, i, tenant.OrgFromContext(ctx))
+		if err != nil {
+			return err
+		}
+
+		// команды-партнера не существует - вставлять нечего
+		if result.RowsAffected() == 0 {
+			return fmt.Errorf("%s: fallback team %s not found", models.ErrCodeNotFound, fallbackTeam)
+		}
+	}
+
+	return nil
+}
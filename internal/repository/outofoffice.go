@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"pr-reviewer-service/internal/models"
+	"pr-reviewer-service/internal/tenant"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// условие для запросов к users: у пользователя нет периода отсутствия, который идет сейчас
+const notOutOfOffice = `NOT EXISTS (
+	SELECT 1 FROM out_of_office ooo
+	WHERE ooo.org_id = users.org_id AND ooo.user_id = users.user_id AND ooo.starts_at <= now() AND ooo.ends_at > now()
+)`
+
+const outOfOfficeColumns = "id, user_id, starts_at, ends_at, reason"
+
+func scanOutOfOffice(row pgx.Row) (*models.OutOfOffice, error) {
+	var period models.OutOfOffice
+	if err := row.Scan(&period.ID, &period.UserID, &period.StartsAt, &period.EndsAt, &period.Reason); err != nil {
+		return nil, err
+	}
+	return &period, nil
+}
+
+// AddOutOfOffice добавляет период отсутствия, ErrNotFound - пользователя не существует
+func (r *Repository) AddOutOfOffice(ctx context.Context, req models.AddOutOfOfficeRequest) (*models.OutOfOffice, error) {
+	period, err := scanOutOfOffice(r.conn(ctx).QueryRow(ctx, `
+		INSERT INTO out_of_office (org_id, user_id, starts_at, ends_at, reason)
+		SELECT org_id, user_id, $2, $3, $4 FROM users WHERE user_id = $1 AND org_id = $5
+		RETURNING `+outOfOfficeColumns, req.UserID, req.StartsAt, req.EndsAt, req.Reason, tenant.OrgFromContext(ctx)))
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return period, nil
+}
+
+// периоды отсутствия пользователя, которые еще не закончились, в порядке начала
+func (r *Repository) GetOutOfOffice(ctx context.Context, userID string) ([]models.OutOfOffice, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT `+outOfOfficeColumns+`
+		FROM out_of_office
+		WHERE user_id = $1 AND org_id = $2 AND ends_at > now()
+		ORDER BY starts_at, id
+	`, userID, tenant.OrgFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	periods := []models.OutOfOffice{}
+	for rows.Next() {
+		period, err := scanOutOfOffice(rows)
+		if err != nil {
+			return nil, err
+		}
+		periods = append(periods, *period)
+	}
+
+	return periods, nil
+}
+
+// DeleteOutOfOffice удаляет период отсутствия пользователя организации, ErrNotFound - периода нет
+func (r *Repository) DeleteOutOfOffice(ctx context.Context, id int64) error {
+	result, err := r.conn(ctx).Exec(ctx, `
+		DELETE FROM out_of_office
+		WHERE id = $1 AND org_id = $2
+	`, id, tenant.OrgFromContext(ctx))
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"pr-reviewer-service/internal/models"
+	"pr-reviewer-service/internal/tenant"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateRepository создает репозиторий с командами-владельцами, ErrAlreadyExists - имя уже занято в организации
+func (r *Repository) CreateRepository(ctx context.Context, repo models.Repository) error {
+	tx, err := r.conn(ctx).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, `
+		INSERT INTO repositories (repository_name, reviewers_per_pr, assignment_strategy, require_senior, required_approvals, org_id)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6)
+		ON CONFLICT (org_id, repository_name) DO NOTHING
+	`, repo.RepositoryName, repo.ReviewersPerPR, repo.AssignmentStrategy, repo.RequireSenior, repo.RequiredApprovals,
+		tenant.OrgFromContext(ctx))
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrAlreadyExists
+	}
+
+	if err := setRepositoryTeams(ctx, tx, repo.RepositoryName, repo.Teams); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// UpdateRepository заменяет настройки репозитория целиком, владельцев - если repo.Teams не nil.
+// ErrNotFound - репозитория нет
+func (r *Repository) UpdateRepository(ctx context.Context, repo models.Repository) error {
+	tx, err := r.conn(ctx).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, `
+		UPDATE repositories
+		SET reviewers_per_pr = $2, assignment_strategy = NULLIF($3, ''), require_senior = $4, required_approvals = $5
+		WHERE repository_name = $1 AND org_id = $6
+	`, repo.RepositoryName, repo.ReviewersPerPR, repo.AssignmentStrategy, repo.RequireSenior, repo.RequiredApprovals,
+		tenant.OrgFromContext(ctx))
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	if repo.Teams != nil {
+		if err := setRepositoryTeams(ctx, tx, repo.RepositoryName, repo.Teams); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// заменяет команды-владельцы репозитория, владелец - команда той же организации
+func setRepositoryTeams(ctx context.Context, tx pgx.Tx, repositoryName string, teams []string) error {
+	_, err := tx.Exec(ctx, `
+		DELETE FROM repository_teams WHERE repository_name = $1 AND org_id = $2
+	`, repositoryName, tenant.OrgFromContext(ctx))
+	if err != nil {
+		return err
+	}
+
+	for _, team := range teams {
+		result, err := tx.Exec(ctx, `
+			INSERT INTO repository_teams (org_id, repository_name, team_name)
+			SELECT org_id, $1, team_name FROM teams WHERE team_name = $2 AND org_id = $3
+		`, repositoryName, team, tenant.OrgFromContext(ctx))
+		if err != nil {
+			return err
+		}
+		if result.RowsAffected() == 0 {
+			return fmt.Errorf("%s: team %s not found", models.ErrCodeNotFound, team)
+		}
+	}
+
+	return nil
+}
+
+func (r *Repository) GetRepository(ctx context.Context, repositoryName string) (*models.Repository, error) {
+	repo := models.Repository{RepositoryName: repositoryName}
+	err := r.conn(ctx).QueryRow(ctx, `
+		SELECT reviewers_per_pr, COALESCE(assignment_strategy, ''), require_senior, required_approvals,
+		       ARRAY(SELECT team_name FROM repository_teams rt
+		             WHERE rt.org_id = repositories.org_id AND rt.repository_name = repositories.repository_name
+		             ORDER BY team_name)
+		FROM repositories
+		WHERE repository_name = $1 AND org_id = $2
+	`, repositoryName, tenant.OrgFromContext(ctx)).Scan(
+		&repo.ReviewersPerPR, &repo.AssignmentStrategy, &repo.RequireSenior, &repo.RequiredApprovals, &repo.Teams,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &repo, nil
+}
@@ -7,16 +7,23 @@ import (
 	"time"
 
 	"pr-reviewer-service/internal/models"
+	"pr-reviewer-service/internal/tenant"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 var (
 	ErrNotFound      = errors.New("not found")
 	ErrAlreadyExists = errors.New("already exists")
+	// PR не в ожидаемом статусе: его изменил параллельный запрос
+	ErrStatusChanged = errors.New("status changed")
 )
 
+// Repository ограничивает все запросы организацией из контекста (tenant.OrgFromContext).
+// Ключи всех таблиц начинаются с org_id, поэтому одинаковые имена и идентификаторы
+// в разных организациях не конфликтуют, а связанные записи не выходят за свою организацию
 type Repository struct {
 	pool *pgxpool.Pool
 }
@@ -25,16 +32,53 @@ func New(pool *pgxpool.Pool) *Repository {
 	return &Repository{pool: pool}
 }
 
-func (r *Repository) CreateTeam(ctx context.Context, teamName string, members []models.TeamMember) error {
-	tx, err := r.pool.Begin(ctx)
+// общий интерфейс пула и транзакции
+type querier interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+}
+
+type txKey struct{}
+
+// WithTx выполняет fn в транзакции: все методы репозитория, вызванные с переданным
+// в fn контекстом, работают внутри нее. Вложенный вызов создает точку сохранения во внешней транзакции
+func (r *Repository) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	tx, err := r.conn(ctx).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// транзакция из контекста, если она есть, иначе пул
+func (r *Repository) conn(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return r.pool
+}
+
+func (r *Repository) CreateTeam(ctx context.Context, teamName string, settings models.TeamSettings, members []models.TeamMember) error {
+	tx, err := r.conn(ctx).Begin(ctx)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback(ctx)
 
-	// проверяем что команды не существует
+	// проверяем что команды не существует в организации
 	var exists bool
-	err = tx.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM teams WHERE team_name = $1)", teamName).Scan(&exists)
+	err = tx.QueryRow(ctx, `
+		SELECT EXISTS(SELECT 1 FROM teams WHERE team_name = $1 AND org_id = $2)
+	`, teamName, tenant.OrgFromContext(ctx)).Scan(&exists)
 	if err != nil {
 		return err
 	}
@@ -43,32 +87,31 @@ func (r *Repository) CreateTeam(ctx context.Context, teamName string, members []
 	}
 
 	// создаем команду
-	_, err = tx.Exec(ctx, "INSERT INTO teams (team_name) VALUES ($1)", teamName)
+	_, err = tx.Exec(ctx, `
+		INSERT INTO teams (team_name, assignment_strategy, reviewers_per_pr, require_senior, pairing_lookback, reassign_on_deactivate,
+		                   review_sla_hours, sla_grace_hours, required_approvals, assignment_rules, org_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, COALESCE($10::jsonb, '[]'), $11)
+	`, teamName, settings.AssignmentStrategy, settings.ReviewersPerPR, settings.RequireSenior, settings.PairingLookback,
+		settings.ReassignOnDeactivate, settings.ReviewSLAHours, settings.SLAGraceHours, settings.RequiredApprovals,
+		settings.AssignmentRules, tenant.OrgFromContext(ctx))
 	if err != nil {
 		return err
 	}
 
+	if err := setTeamFallbacks(ctx, tx, teamName, settings.FallbackTeams); err != nil {
+		return err
+	}
+
 	// добавляем участников
-	for _, member := range members {
-		_, err = tx.Exec(ctx, `
-			INSERT INTO users (user_id, username, team_name, is_active)
-			VALUES ($1, $2, $3, $4)
-			ON CONFLICT (user_id) DO UPDATE
-			SET username = EXCLUDED.username,
-			    team_name = EXCLUDED.team_name,
-			    is_active = EXCLUDED.is_active,
-			    updated_at = CURRENT_TIMESTAMP
-		`, member.UserID, member.Username, teamName, member.IsActive)
-		if err != nil {
-			return err
-		}
+	if err := upsertMembers(ctx, tx, teamName, members); err != nil {
+		return err
 	}
 
 	return tx.Commit(ctx)
 }
 
-func (r *Repository) UpdateTeam(ctx context.Context, teamName string, members []models.TeamMember) error {
-	tx, err := r.pool.Begin(ctx)
+func (r *Repository) UpdateTeam(ctx context.Context, teamName string, settings models.TeamSettings, members []models.TeamMember) error {
+	tx, err := r.conn(ctx).Begin(ctx)
 	if err != nil {
 		return err
 	}
@@ -76,7 +119,9 @@ func (r *Repository) UpdateTeam(ctx context.Context, teamName string, members []
 
 	// проверяем что команда существует
 	var exists bool
-	err = tx.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM teams WHERE team_name = $1)", teamName).Scan(&exists)
+	err = tx.QueryRow(ctx, `
+		SELECT EXISTS(SELECT 1 FROM teams WHERE team_name = $1 AND org_id = $2)
+	`, teamName, tenant.OrgFromContext(ctx)).Scan(&exists)
 	if err != nil {
 		return err
 	}
@@ -84,42 +129,96 @@ func (r *Repository) UpdateTeam(ctx context.Context, teamName string, members []
 		return fmt.Errorf("%s: team not found", models.ErrCodeNotFound)
 	}
 
+	// обновляем настройки команды
+	_, err = tx.Exec(ctx, `
+		UPDATE teams
+		SET assignment_strategy = $1, reviewers_per_pr = $2, require_senior = $3, pairing_lookback = $4,
+		    reassign_on_deactivate = $5, review_sla_hours = $6, sla_grace_hours = $7, required_approvals = $8,
+		    assignment_rules = COALESCE($9::jsonb, '[]')
+		WHERE team_name = $10 AND org_id = $11
+	`, settings.AssignmentStrategy, settings.ReviewersPerPR, settings.RequireSenior, settings.PairingLookback,
+		settings.ReassignOnDeactivate, settings.ReviewSLAHours, settings.SLAGraceHours, settings.RequiredApprovals,
+		settings.AssignmentRules, teamName, tenant.OrgFromContext(ctx))
+	if err != nil {
+		return err
+	}
+
+	if err := setTeamFallbacks(ctx, tx, teamName, settings.FallbackTeams); err != nil {
+		return err
+	}
+
 	// обновляем участников команды
+	if err := upsertMembers(ctx, tx, teamName, members); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// создает или обновляет участников команды; если теги, расписание (nil), уровень или часовой пояс
+// (пустая строка) участника не переданы, у существующего пользователя они остаются прежними
+func upsertMembers(ctx context.Context, tx pgx.Tx, teamName string, members []models.TeamMember) error {
 	for _, member := range members {
-		_, err = tx.Exec(ctx, `
-			INSERT INTO users (user_id, username, team_name, is_active)
-			VALUES ($1, $2, $3, $4)
-			ON CONFLICT (user_id) DO UPDATE
+		_, err := tx.Exec(ctx, `
+			INSERT INTO users (user_id, username, team_name, is_active, tags, level, timezone, working_hours, org_id)
+			VALUES ($1, $2, $3, $4, COALESCE($5::text[], '{}'), COALESCE(NULLIF($6, ''), 'middle'),
+			        COALESCE(NULLIF($7, ''), 'UTC'), $8::jsonb, $9)
+			ON CONFLICT (org_id, user_id) DO UPDATE
 			SET username = EXCLUDED.username,
 			    team_name = EXCLUDED.team_name,
 			    is_active = EXCLUDED.is_active,
+			    tags = COALESCE($5::text[], users.tags),
+			    level = COALESCE(NULLIF($6, ''), users.level),
+			    timezone = COALESCE(NULLIF($7, ''), users.timezone),
+			    working_hours = COALESCE($8::jsonb, users.working_hours),
 			    updated_at = CURRENT_TIMESTAMP
-		`, member.UserID, member.Username, teamName, member.IsActive)
+		`, member.UserID, member.Username, teamName, member.IsActive, member.Tags, member.Level,
+			member.Timezone, member.WorkingHours, tenant.OrgFromContext(ctx))
 		if err != nil {
 			return err
 		}
 	}
+	return nil
+}
 
-	return tx.Commit(ctx)
+func (r *Repository) GetTeamSettings(ctx context.Context, teamName string) (*models.TeamSettings, error) {
+	var settings models.TeamSettings
+	err := r.conn(ctx).QueryRow(ctx, `
+		SELECT assignment_strategy, reviewers_per_pr, require_senior, pairing_lookback, reassign_on_deactivate,
+		       review_sla_hours, sla_grace_hours, required_approvals, assignment_rules,
+		       ARRAY(SELECT fallback_team FROM team_fallbacks f
+		             WHERE f.org_id = teams.org_id AND f.team_name = teams.team_name ORDER BY priority)
+		FROM teams
+		WHERE team_name = $1 AND org_id = $2
+	`, teamName, tenant.OrgFromContext(ctx)).Scan(
+		&settings.AssignmentStrategy, &settings.ReviewersPerPR, &settings.RequireSenior, &settings.PairingLookback,
+		&settings.ReassignOnDeactivate, &settings.ReviewSLAHours, &settings.SLAGraceHours, &settings.RequiredApprovals,
+		&settings.AssignmentRules, &settings.FallbackTeams,
+	)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &settings, nil
 }
 
 func (r *Repository) GetTeam(ctx context.Context, teamName string) (*models.Team, error) {
-	var exists bool
-	err := r.pool.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM teams WHERE team_name = $1)", teamName).Scan(&exists)
+	settings, err := r.GetTeamSettings(ctx, teamName)
 	if err != nil {
 		return nil, err
 	}
-	if !exists {
-		return nil, ErrNotFound
-	}
 
 	// получаем список участников команды
-	rows, err := r.pool.Query(ctx, `
-		SELECT user_id, username, is_active
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT user_id, username, is_active, tags, level, timezone, working_hours
 		FROM users
-		WHERE team_name = $1
+		WHERE team_name = $1 AND org_id = $2
 		ORDER BY user_id
-	`, teamName)
+	`, teamName, tenant.OrgFromContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -128,25 +227,44 @@ func (r *Repository) GetTeam(ctx context.Context, teamName string) (*models.Team
 	members := []models.TeamMember{}
 	for rows.Next() {
 		var member models.TeamMember
-		if err := rows.Scan(&member.UserID, &member.Username, &member.IsActive); err != nil {
+		err := rows.Scan(
+			&member.UserID, &member.Username, &member.IsActive, &member.Tags, &member.Level,
+			&member.Timezone, &member.WorkingHours,
+		)
+		if err != nil {
 			return nil, err
 		}
 		members = append(members, member)
 	}
 
 	return &models.Team{
-		TeamName: teamName,
-		Members:  members,
+		TeamName:     teamName,
+		TeamSettings: *settings,
+		Members:      members,
 	}, nil
 }
 
-func (r *Repository) GetUser(ctx context.Context, userID string) (*models.User, error) {
+// колонки users в порядке, который ожидает scanUser
+const userColumns = "user_id, username, team_name, is_active, tags, level, timezone, working_hours, max_open_reviews"
+
+func scanUser(row pgx.Row) (*models.User, error) {
 	var user models.User
-	err := r.pool.QueryRow(ctx, `
-		SELECT user_id, username, team_name, is_active
+	err := row.Scan(
+		&user.UserID, &user.Username, &user.TeamName, &user.IsActive, &user.Tags, &user.Level,
+		&user.Timezone, &user.WorkingHours, &user.MaxOpenReviews,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *Repository) GetUser(ctx context.Context, userID string) (*models.User, error) {
+	user, err := scanUser(r.conn(ctx).QueryRow(ctx, `
+		SELECT `+userColumns+`
 		FROM users
-		WHERE user_id = $1
-	`, userID).Scan(&user.UserID, &user.Username, &user.TeamName, &user.IsActive)
+		WHERE user_id = $1 AND org_id = $2
+	`, userID, tenant.OrgFromContext(ctx)))
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -155,17 +273,39 @@ func (r *Repository) GetUser(ctx context.Context, userID string) (*models.User,
 		return nil, err
 	}
 
-	return &user, nil
+	return user, nil
+}
+
+// пользователи из списка независимо от активности, неизвестные пропускаются
+func (r *Repository) GetUsers(ctx context.Context, userIDs []string) ([]models.User, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT `+userColumns+`
+		FROM users
+		WHERE user_id = ANY($1) AND org_id = $2
+	`, userIDs, tenant.OrgFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *user)
+	}
+
+	return users, nil
 }
 
 func (r *Repository) SetUserActive(ctx context.Context, userID string, isActive bool) (*models.User, error) {
-	var user models.User
-	err := r.pool.QueryRow(ctx, `
+	user, err := scanUser(r.conn(ctx).QueryRow(ctx, `
 		UPDATE users
 		SET is_active = $1, updated_at = CURRENT_TIMESTAMP
-		WHERE user_id = $2
-		RETURNING user_id, username, team_name, is_active
-	`, isActive, userID).Scan(&user.UserID, &user.Username, &user.TeamName, &user.IsActive)
+		WHERE user_id = $2 AND org_id = $3
+		RETURNING `+userColumns, isActive, userID, tenant.OrgFromContext(ctx)))
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -174,15 +314,33 @@ func (r *Repository) SetUserActive(ctx context.Context, userID string, isActive
 		return nil, err
 	}
 
-	return &user, nil
+	return user, nil
+}
+
+// SetUserMaxOpenReviews задает лимит открытых ревью пользователя, nil снимает ограничение
+func (r *Repository) SetUserMaxOpenReviews(ctx context.Context, userID string, limit *int) (*models.User, error) {
+	user, err := scanUser(r.conn(ctx).QueryRow(ctx, `
+		UPDATE users
+		SET max_open_reviews = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = $2 AND org_id = $3
+		RETURNING `+userColumns, limit, userID, tenant.OrgFromContext(ctx)))
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return user, nil
 }
 
 func (r *Repository) GetActiveTeamMembers(ctx context.Context, teamName string, excludeUserID string) ([]models.User, error) {
-	rows, err := r.pool.Query(ctx, `
-		SELECT user_id, username, team_name, is_active
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT `+userColumns+`
 		FROM users
-		WHERE team_name = $1 AND is_active = true AND user_id != $2
-	`, teamName, excludeUserID)
+		WHERE team_name = $1 AND org_id = $3 AND is_active = true AND user_id != $2 AND `+notOutOfOffice+`
+	`, teamName, excludeUserID, tenant.OrgFromContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -190,26 +348,84 @@ func (r *Repository) GetActiveTeamMembers(ctx context.Context, teamName string,
 
 	users := []models.User{}
 	for rows.Next() {
-		var user models.User
-		if err := rows.Scan(&user.UserID, &user.Username, &user.TeamName, &user.IsActive); err != nil {
+		user, err := scanUser(rows)
+		if err != nil {
 			return nil, err
 		}
-		users = append(users, user)
+		users = append(users, *user)
 	}
 
 	return users, nil
 }
 
-func (r *Repository) CreatePR(ctx context.Context, pr *models.PullRequest, reviewers []string) error {
-	tx, err := r.pool.Begin(ctx)
+// количество открытых ревью для каждого из пользователей, пользователи без ревью в карту не попадают
+func (r *Repository) GetOpenReviewCounts(ctx context.Context, userIDs []string) (map[string]int, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT prr.user_id, COUNT(*)
+		FROM pull_request_reviewers prr
+		INNER JOIN pull_requests pr ON pr.org_id = prr.org_id AND pr.pull_request_id = prr.pull_request_id
+		WHERE pr.status = $1 AND prr.user_id = ANY($2) AND prr.org_id = $3
+		GROUP BY prr.user_id
+	`, models.StatusOpen, userIDs, tenant.OrgFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int, len(userIDs))
+	for rows.Next() {
+		var userID string
+		var count int
+		if err := rows.Scan(&userID, &count); err != nil {
+			return nil, err
+		}
+		counts[userID] = count
+	}
+
+	return counts, nil
+}
+
+// ревьюверы последних limit PR автора
+func (r *Repository) GetRecentReviewers(ctx context.Context, authorID string, limit int) (map[string]bool, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT DISTINCT prr.user_id
+		FROM pull_request_reviewers prr
+		WHERE prr.org_id = $3 AND prr.pull_request_id IN (
+			SELECT pull_request_id FROM pull_requests
+			WHERE author_id = $1 AND org_id = $3
+			ORDER BY created_at DESC
+			LIMIT $2
+		)
+	`, authorID, limit, tenant.OrgFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reviewers := make(map[string]bool)
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		reviewers[userID] = true
+	}
+
+	return reviewers, nil
+}
+
+func (r *Repository) CreatePR(ctx context.Context, pr *models.PullRequest, reviewers []models.ReviewerInfo) error {
+	tx, err := r.conn(ctx).Begin(ctx)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback(ctx)
 
-	// проверяем что PR с таким id еще не существует
+	// проверяем что PR с таким id еще не существует в организации
 	var exists bool
-	err = tx.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM pull_requests WHERE pull_request_id = $1)", pr.PullRequestID).Scan(&exists)
+	err = tx.QueryRow(ctx, `
+		SELECT EXISTS(SELECT 1 FROM pull_requests WHERE pull_request_id = $1 AND org_id = $2)
+	`, pr.PullRequestID, tenant.OrgFromContext(ctx)).Scan(&exists)
 	if err != nil {
 		return err
 	}
@@ -219,19 +435,34 @@ func (r *Repository) CreatePR(ctx context.Context, pr *models.PullRequest, revie
 
 	now := time.Now()
 	_, err = tx.Exec(ctx, `
-		INSERT INTO pull_requests (pull_request_id, pull_request_name, author_id, status, created_at)
-		VALUES ($1, $2, $3, $4, $5)
-	`, pr.PullRequestID, pr.PullRequestName, pr.AuthorID, models.StatusOpen, now)
+		INSERT INTO pull_requests (
+			pull_request_id, pull_request_name, author_id, status, created_at, required_tags, changed_files, ownership_match,
+			lines_added, lines_removed, files_changed, priority, labels, repository_name, pr_number, org_id
+		)
+		VALUES ($1, $2, $3, $4, $5, COALESCE($6::text[], '{}'), COALESCE($7::text[], '{}'), $8,
+		        $9, $10, $11, COALESCE(NULLIF($12, ''), 'normal'), COALESCE($13::text[], '{}'),
+		        NULLIF($14, ''), NULLIF($15, 0), $16)
+	`, pr.PullRequestID, pr.PullRequestName, pr.AuthorID, pr.Status, now,
+		pr.RequiredTags, pr.ChangedFiles, pr.OwnershipMatch,
+		pr.LinesAdded, pr.LinesRemoved, pr.FilesChanged, pr.Priority, pr.Labels, pr.Repository, pr.Number,
+		tenant.OrgFromContext(ctx))
 	if err != nil {
 		return err
 	}
 
+	// открытый PR сразу начинает первый раунд ревью, черновик - при переводе в ready
+	if pr.Status == models.StatusOpen {
+		if err := startFirstReviewRound(ctx, tx, pr.PullRequestID); err != nil {
+			return err
+		}
+	}
+
 	// назначаем ревьюверов
-	for _, reviewerID := range reviewers {
+	for _, reviewer := range reviewers {
 		_, err = tx.Exec(ctx, `
-			INSERT INTO pull_request_reviewers (pull_request_id, user_id)
-			VALUES ($1, $2)
-		`, pr.PullRequestID, reviewerID)
+			INSERT INTO pull_request_reviewers (pull_request_id, user_id, fallback_team, org_id)
+			VALUES ($1, $2, NULLIF($3, ''), $4)
+		`, pr.PullRequestID, reviewer.UserID, reviewer.FallbackTeam, tenant.OrgFromContext(ctx))
 		if err != nil {
 			return err
 		}
@@ -242,13 +473,23 @@ func (r *Repository) CreatePR(ctx context.Context, pr *models.PullRequest, revie
 
 func (r *Repository) GetPR(ctx context.Context, prID string) (*models.PullRequest, error) {
 	var pr models.PullRequest
-	var createdAt, mergedAt *time.Time
-
-	err := r.pool.QueryRow(ctx, `
-		SELECT pull_request_id, pull_request_name, author_id, status, created_at, merged_at
+	var createdAt, mergedAt, closedAt *time.Time
+
+	err := r.conn(ctx).QueryRow(ctx, `
+		SELECT pull_request_id, pull_request_name, author_id, status, created_at, merged_at, closed_at,
+			required_tags, changed_files, ownership_match,
+			lines_added, lines_removed, files_changed, priority, labels,
+			COALESCE(repository_name, ''), COALESCE(pr_number, 0),
+			(SELECT COALESCE(MAX(round), 0) FROM review_rounds rr
+			 WHERE rr.org_id = pull_requests.org_id AND rr.pull_request_id = pull_requests.pull_request_id)
 		FROM pull_requests
-		WHERE pull_request_id = $1
-	`, prID).Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status, &createdAt, &mergedAt)
+		WHERE pull_request_id = $1 AND org_id = $2
+	`, prID, tenant.OrgFromContext(ctx)).Scan(
+		&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status, &createdAt, &mergedAt, &closedAt,
+		&pr.RequiredTags, &pr.ChangedFiles, &pr.OwnershipMatch,
+		&pr.LinesAdded, &pr.LinesRemoved, &pr.FilesChanged, &pr.Priority, &pr.Labels,
+		&pr.Repository, &pr.Number, &pr.ReviewRound,
+	)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -259,110 +500,181 @@ func (r *Repository) GetPR(ctx context.Context, prID string) (*models.PullReques
 
 	pr.CreatedAt = createdAt
 	pr.MergedAt = mergedAt
+	pr.ClosedAt = closedAt
 
 	// Get reviewers
-	rows, err := r.pool.Query(ctx, `
-		SELECT user_id FROM pull_request_reviewers WHERE pull_request_id = $1
-	`, prID)
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT user_id, COALESCE(fallback_team, ''), overdue_at, COALESCE(verdict, ''), verdict_at
+		FROM pull_request_reviewers
+		WHERE pull_request_id = $1 AND org_id = $2
+		ORDER BY assigned_at, user_id
+	`, prID, tenant.OrgFromContext(ctx))
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	reviewers := []string{}
+	details := []models.ReviewerInfo{}
 	for rows.Next() {
-		var reviewerID string
-		if err := rows.Scan(&reviewerID); err != nil {
+		var reviewer models.ReviewerInfo
+		err := rows.Scan(&reviewer.UserID, &reviewer.FallbackTeam, &reviewer.OverdueAt, &reviewer.Verdict, &reviewer.VerdictAt)
+		if err != nil {
 			return nil, err
 		}
-		reviewers = append(reviewers, reviewerID)
+		reviewers = append(reviewers, reviewer.UserID)
+		details = append(details, reviewer)
 	}
 	pr.AssignedReviewers = reviewers
+	pr.Reviewers = details
 
 	return &pr, nil
 }
 
 func (r *Repository) MergePR(ctx context.Context, prID string) (*models.PullRequest, error) {
-	now := time.Now()
-	var pr models.PullRequest
-	var createdAt, mergedAt *time.Time
-
-	err := r.pool.QueryRow(ctx, `
+	result, err := r.conn(ctx).Exec(ctx, `
 		UPDATE pull_requests
 		SET status = $1, merged_at = $2
-		WHERE pull_request_id = $3
-		RETURNING pull_request_id, pull_request_name, author_id, status, created_at, merged_at
-	`, models.StatusMerged, now, prID).Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status, &createdAt, &mergedAt)
-
+		WHERE pull_request_id = $3 AND org_id = $4
+	`, models.StatusMerged, time.Now(), prID, tenant.OrgFromContext(ctx))
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, ErrNotFound
-		}
 		return nil, err
 	}
+	if result.RowsAffected() == 0 {
+		return nil, ErrNotFound
+	}
 
-	pr.CreatedAt = createdAt
-	pr.MergedAt = mergedAt
+	return r.GetPR(ctx, prID)
+}
 
-	// Get reviewers
-	rows, err := r.pool.Query(ctx, `
-		SELECT user_id FROM pull_request_reviewers WHERE pull_request_id = $1
-	`, prID)
+// ClosePR закрывает открытый PR или черновик без merge, ревьюверы остаются назначенными.
+// ErrStatusChanged - PR уже закрыт или слит
+func (r *Repository) ClosePR(ctx context.Context, prID string) error {
+	result, err := r.conn(ctx).Exec(ctx, `
+		UPDATE pull_requests
+		SET status = $1, closed_at = $2
+		WHERE pull_request_id = $3 AND org_id = $4 AND status IN ($5, $6)
+	`, models.StatusClosed, time.Now(), prID, tenant.OrgFromContext(ctx), models.StatusOpen, models.StatusDraft)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer rows.Close()
+	if result.RowsAffected() == 0 {
+		return ErrStatusChanged
+	}
+	return nil
+}
 
-	reviewers := []string{}
-	for rows.Next() {
-		var reviewerID string
-		if err := rows.Scan(&reviewerID); err != nil {
-			return nil, err
+// ReopenPR снова открывает закрытый PR. Время назначения ревьюверов сбрасывается,
+// чтобы SLA ревью отсчитывался заново, а не с момента назначения до закрытия.
+// ErrStatusChanged - PR уже не закрыт
+func (r *Repository) ReopenPR(ctx context.Context, prID string) error {
+	tx, err := r.conn(ctx).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, `
+		UPDATE pull_requests
+		SET status = $1, closed_at = NULL
+		WHERE pull_request_id = $2 AND org_id = $3 AND status = $4
+	`, models.StatusOpen, prID, tenant.OrgFromContext(ctx), models.StatusClosed)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrStatusChanged
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE pull_request_reviewers
+		SET assigned_at = CURRENT_TIMESTAMP, overdue_at = NULL
+		WHERE pull_request_id = $1 AND org_id = $2
+	`, prID, tenant.OrgFromContext(ctx))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// OpenPRForReview переводит PR из статуса from в OPEN и назначает выбранных ревьюверов (черновик,
+// готовый к ревью, или закрытый PR без ревьюверов). ErrStatusChanged - PR уже не в статусе from
+func (r *Repository) OpenPRForReview(
+	ctx context.Context, prID, from string, ownership *models.OwnershipMatch, reviewers []models.ReviewerInfo,
+) error {
+	tx, err := r.conn(ctx).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, `
+		UPDATE pull_requests
+		SET status = $1, closed_at = NULL, ownership_match = $2
+		WHERE pull_request_id = $3 AND org_id = $4 AND status = $5
+	`, models.StatusOpen, ownership, prID, tenant.OrgFromContext(ctx), from)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrStatusChanged
+	}
+
+	if err := startFirstReviewRound(ctx, tx, prID); err != nil {
+		return err
+	}
+
+	for _, reviewer := range reviewers {
+		_, err = tx.Exec(ctx, `
+			INSERT INTO pull_request_reviewers (pull_request_id, user_id, fallback_team, org_id)
+			VALUES ($1, $2, NULLIF($3, ''), $4)
+		`, prID, reviewer.UserID, reviewer.FallbackTeam, tenant.OrgFromContext(ctx))
+		if err != nil {
+			return err
 		}
-		reviewers = append(reviewers, reviewerID)
 	}
-	pr.AssignedReviewers = reviewers
 
-	return &pr, nil
+	return tx.Commit(ctx)
 }
 
 func (r *Repository) IsReviewerAssigned(ctx context.Context, prID, userID string) (bool, error) {
 	var exists bool
-	err := r.pool.QueryRow(ctx, `
+	err := r.conn(ctx).QueryRow(ctx, `
 		SELECT EXISTS(
 			SELECT 1 FROM pull_request_reviewers
-			WHERE pull_request_id = $1 AND user_id = $2
+			WHERE pull_request_id = $1 AND user_id = $2 AND org_id = $3
 		)
-	`, prID, userID).Scan(&exists)
+	`, prID, userID, tenant.OrgFromContext(ctx)).Scan(&exists)
 	return exists, err
 }
 
 func (r *Repository) ReassignReviewer(ctx context.Context, prID, oldUserID, newUserID string) error {
-	tx, err := r.pool.Begin(ctx)
+	tx, err := r.conn(ctx).Begin(ctx)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback(ctx)
 
 	// Remove old reviewer
-	result, err := tx.Exec(ctx, `
+	var fallbackTeam *string
+	err = tx.QueryRow(ctx, `
 		DELETE FROM pull_request_reviewers
-		WHERE pull_request_id = $1 AND user_id = $2
-	`, prID, oldUserID)
+		WHERE pull_request_id = $1 AND user_id = $2 AND org_id = $3
+		RETURNING fallback_team
+	`, prID, oldUserID, tenant.OrgFromContext(ctx)).Scan(&fallbackTeam)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("reviewer not found in PR")
+		}
 		return err
 	}
 
-	rowsAffected := result.RowsAffected()
-	if rowsAffected == 0 {
-		return fmt.Errorf("reviewer not found in PR")
-	}
-
 	// Add new reviewer
 	_, err = tx.Exec(ctx, `
-		INSERT INTO pull_request_reviewers (pull_request_id, user_id)
-		VALUES ($1, $2)
-	`, prID, newUserID)
+		INSERT INTO pull_request_reviewers (pull_request_id, user_id, fallback_team, org_id)
+		VALUES ($1, $2, $3, $4)
+	`, prID, newUserID, fallbackTeam, tenant.OrgFromContext(ctx))
 	if err != nil {
 		return err
 	}
@@ -370,14 +682,45 @@ func (r *Repository) ReassignReviewer(ctx context.Context, prID, oldUserID, newU
 	return tx.Commit(ctx)
 }
 
+// AddReviewer назначает ревьювера на PR, ErrAlreadyExists - он уже назначен
+func (r *Repository) AddReviewer(ctx context.Context, prID string, reviewer models.ReviewerInfo) error {
+	result, err := r.conn(ctx).Exec(ctx, `
+		INSERT INTO pull_request_reviewers (pull_request_id, user_id, fallback_team, org_id)
+		VALUES ($1, $2, NULLIF($3, ''), $4)
+		ON CONFLICT (org_id, pull_request_id, user_id) DO NOTHING
+	`, prID, reviewer.UserID, reviewer.FallbackTeam, tenant.OrgFromContext(ctx))
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrAlreadyExists
+	}
+	return nil
+}
+
+// RemoveReviewer снимает ревьювера с PR, ErrNotFound - он не был назначен
+func (r *Repository) RemoveReviewer(ctx context.Context, prID, userID string) error {
+	result, err := r.conn(ctx).Exec(ctx, `
+		DELETE FROM pull_request_reviewers
+		WHERE pull_request_id = $1 AND user_id = $2 AND org_id = $3
+	`, prID, userID, tenant.OrgFromContext(ctx))
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
 func (r *Repository) GetPRsByReviewer(ctx context.Context, userID string) ([]models.PullRequestShort, error) {
-	rows, err := r.pool.Query(ctx, `
-		SELECT pr.pull_request_id, pr.pull_request_name, pr.author_id, pr.status
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT pr.pull_request_id, pr.pull_request_name, pr.author_id, pr.status, COALESCE(pr.repository_name, '')
 		FROM pull_requests pr
-		INNER JOIN pull_request_reviewers prr ON pr.pull_request_id = prr.pull_request_id
-		WHERE prr.user_id = $1
+		INNER JOIN pull_request_reviewers prr ON pr.org_id = prr.org_id AND pr.pull_request_id = prr.pull_request_id
+		WHERE prr.user_id = $1 AND pr.org_id = $2
 		ORDER BY pr.created_at DESC
-	`, userID)
+	`, userID, tenant.OrgFromContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -386,7 +729,7 @@ func (r *Repository) GetPRsByReviewer(ctx context.Context, userID string) ([]mod
 	prs := []models.PullRequestShort{}
 	for rows.Next() {
 		var pr models.PullRequestShort
-		if err := rows.Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status); err != nil {
+		if err := rows.Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status, &pr.Repository); err != nil {
 			return nil, err
 		}
 		prs = append(prs, pr)
@@ -395,7 +738,7 @@ func (r *Repository) GetPRsByReviewer(ctx context.Context, userID string) ([]mod
 	return prs, nil
 }
 
-// получение статистики по пользователям
+// получение статистики по пользователям организации
 func (r *Repository) GetUserStats(ctx context.Context) ([]models.UserStats, error) {
 	query := `
 		SELECT
@@ -403,18 +746,35 @@ func (r *Repository) GetUserStats(ctx context.Context) ([]models.UserStats, erro
 			u.username,
 			u.team_name,
 			u.is_active,
+			u.max_open_reviews,
 			COUNT(DISTINCT pr_authored.pull_request_id) as total_prs_authored,
 			COUNT(DISTINCT prr.pull_request_id) as total_reviews,
-			COUNT(DISTINCT CASE WHEN pr_review.status = 'OPEN' THEN prr.pull_request_id END) as active_reviews
+			COUNT(DISTINCT CASE WHEN pr_review.status = 'OPEN' THEN prr.pull_request_id END) as active_reviews,
+			(SELECT COUNT(*) FROM review_declines d WHERE d.org_id = u.org_id AND d.user_id = u.user_id) as total_declines,
+			rounds.review_rounds,
+			rounds.avg_latency_hours
 		FROM users u
-		LEFT JOIN pull_requests pr_authored ON u.user_id = pr_authored.author_id
-		LEFT JOIN pull_request_reviewers prr ON u.user_id = prr.user_id
-		LEFT JOIN pull_requests pr_review ON prr.pull_request_id = pr_review.pull_request_id
-		GROUP BY u.user_id, u.username, u.team_name, u.is_active
+		LEFT JOIN pull_requests pr_authored ON u.org_id = pr_authored.org_id AND u.user_id = pr_authored.author_id
+		LEFT JOIN pull_request_reviewers prr ON u.org_id = prr.org_id AND u.user_id = prr.user_id
+		LEFT JOIN pull_requests pr_review ON prr.org_id = pr_review.org_id AND prr.pull_request_id = pr_review.pull_request_id
+		-- время от запроса ревью до первого итога пользователя в каждом раунде
+		CROSS JOIN LATERAL (
+			SELECT COUNT(*) as review_rounds,
+			       COALESCE(AVG(EXTRACT(EPOCH FROM first_at - requested_at) / 3600), 0)::float8 as avg_latency_hours
+			FROM (
+				SELECT MIN(v.submitted_at) as first_at, MIN(v.requested_at) as requested_at
+				FROM review_verdicts v
+				WHERE v.org_id = u.org_id AND v.user_id = u.user_id
+				GROUP BY v.pull_request_id, v.round
+			) first_verdicts
+		) rounds
+		WHERE u.org_id = $1
+		GROUP BY u.org_id, u.user_id, u.username, u.team_name, u.is_active, u.max_open_reviews,
+			rounds.review_rounds, rounds.avg_latency_hours
 		ORDER BY total_reviews DESC, total_prs_authored DESC
 	`
 
-	rows, err := r.pool.Query(ctx, query)
+	rows, err := r.conn(ctx).Query(ctx, query, tenant.OrgFromContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -423,7 +783,12 @@ func (r *Repository) GetUserStats(ctx context.Context) ([]models.UserStats, erro
 	stats := []models.UserStats{}
 	for rows.Next() {
 		var s models.UserStats
-		if err := rows.Scan(&s.UserID, &s.Username, &s.TeamName, &s.IsActive, &s.TotalPRsAuthored, &s.TotalReviews, &s.ActiveReviews); err != nil {
+		err := rows.Scan(
+			&s.UserID, &s.Username, &s.TeamName, &s.IsActive, &s.MaxOpenReviews,
+			&s.TotalPRsAuthored, &s.TotalReviews, &s.ActiveReviews, &s.TotalDeclines,
+			&s.ReviewRounds, &s.AvgRoundLatencyHours,
+		)
+		if err != nil {
 			return nil, err
 		}
 		stats = append(stats, s)
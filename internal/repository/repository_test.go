@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"pr-reviewer-service/internal/models"
+	"pr-reviewer-service/internal/tenant"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// newTestRepository применяет миграции в отдельной схеме базы TEST_DATABASE_URL и возвращает
+// репозиторий, работающий в этой схеме. Без TEST_DATABASE_URL тест пропускается
+func newTestRepository(t *testing.T) *Repository {
+	t.Helper()
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	admin, err := pgxpool.New(ctx, url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if _, err := admin.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		admin.Close()
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		admin.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE")
+		admin.Close()
+	})
+
+	config, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	config.ConnConfig.RuntimeParams["search_path"] = schema
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	files, err := filepath.Glob("../../migrations/*.up.sql")
+	if err != nil {
+		t.Fatalf("list migrations: %v", err)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		sql, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("read %s: %v", file, err)
+		}
+		if _, err := pool.Exec(ctx, string(sql)); err != nil {
+			t.Fatalf("apply %s: %v", filepath.Base(file), err)
+		}
+	}
+
+	return New(pool)
+}
+
+func TestGetUserStats(t *testing.T) {
+	repo := newTestRepository(t)
+
+	settings := models.TeamSettings{AssignmentStrategy: "least_loaded", ReviewersPerPR: 2, SLAGraceHours: 8}
+	members := []models.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+		{UserID: "u2", Username: "Bob", IsActive: true},
+	}
+
+	// одинаковые команды и пользователи в двух организациях
+	billing := tenant.WithOrg(context.Background(), "billing")
+	search := tenant.WithOrg(context.Background(), "search")
+	for _, ctx := range []context.Context{billing, search} {
+		if err := repo.CreateTeam(ctx, "backend", settings, members); err != nil {
+			t.Fatalf("CreateTeam() error: %v", err)
+		}
+	}
+
+	pr := &models.PullRequest{PullRequestID: "pr-1", PullRequestName: "Add search", AuthorID: "u1", Status: models.StatusOpen}
+	if err := repo.CreatePR(billing, pr, []models.ReviewerInfo{{UserID: "u2"}}); err != nil {
+		t.Fatalf("CreatePR() error: %v", err)
+	}
+	if err := repo.AddReviewDecline(billing, &models.ReviewDecline{PullRequestID: "pr-1", UserID: "u2", Reason: "busy"}); err != nil {
+		t.Fatalf("AddReviewDecline() error: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		ctx          context.Context
+		wantAuthored int
+		wantReviews  int
+		wantDeclines int
+	}{
+		{name: "организация с PR", ctx: billing, wantAuthored: 0, wantReviews: 1, wantDeclines: 1},
+		{name: "другая организация с теми же user_id", ctx: search},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats, err := repo.GetUserStats(tt.ctx)
+			if err != nil {
+				t.Fatalf("GetUserStats() error: %v", err)
+			}
+			if len(stats) != 2 {
+				t.Fatalf("got %d users, want 2", len(stats))
+			}
+			for _, s := range stats {
+				if s.UserID != "u2" {
+					continue
+				}
+				if s.TotalPRsAuthored != tt.wantAuthored || s.TotalReviews != tt.wantReviews || s.TotalDeclines != tt.wantDeclines {
+					t.Errorf("u2 stats = authored %d, reviews %d, declines %d, want %d, %d, %d",
+						s.TotalPRsAuthored, s.TotalReviews, s.TotalDeclines, tt.wantAuthored, tt.wantReviews, tt.wantDeclines)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"pr-reviewer-service/internal/models"
+	"pr-reviewer-service/internal/tenant"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// SetReviewVerdict сохраняет итог ревью в текущем раунде: повторный ответ заменяет предыдущий
+// у ревьювера, а в истории раунда сохраняются все ответы. ErrNotFound - пользователь не назначен ревьювером PR
+func (r *Repository) SetReviewVerdict(ctx context.Context, prID, userID, verdict string) error {
+	result, err := r.conn(ctx).Exec(ctx, `
+		WITH updated AS (
+			UPDATE pull_request_reviewers
+			SET verdict = $3, verdict_at = CURRENT_TIMESTAMP
+			WHERE pull_request_id = $1 AND user_id = $2 AND org_id = $4
+			RETURNING org_id, pull_request_id, user_id, verdict, assigned_at, verdict_at
+		)
+		INSERT INTO review_verdicts (org_id, pull_request_id, round, user_id, verdict, requested_at, submitted_at)
+		SELECT u.org_id, u.pull_request_id, rr.round, u.user_id, u.verdict, COALESCE(u.assigned_at, rr.started_at), u.verdict_at
+		FROM updated u
+		CROSS JOIN LATERAL (
+			SELECT round, started_at FROM review_rounds
+			WHERE org_id = u.org_id AND pull_request_id = u.pull_request_id
+			ORDER BY round DESC
+			LIMIT 1
+		) rr
+	`, prID, userID, verdict, tenant.OrgFromContext(ctx))
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// первый раунд начинается, когда PR открывается для ревью; повторное открытие раунд не меняет
+func startFirstReviewRound(ctx context.Context, tx pgx.Tx, prID string) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO review_rounds (org_id, pull_request_id, round)
+		VALUES ($2, $1, 1)
+		ON CONFLICT DO NOTHING
+	`, prID, tenant.OrgFromContext(ctx))
+	return err
+}
+
+// StartReviewRound начинает следующий раунд ревью PR: итоги ревьюверов сбрасываются (в истории
+// прошлых раундов они сохраняются), а срок SLA отсчитывается заново. Возвращает номер нового раунда
+func (r *Repository) StartReviewRound(ctx context.Context, prID string) (int, error) {
+	tx, err := r.conn(ctx).Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	// блокировка PR, чтобы параллельные запросы не получили один номер раунда
+	var locked string
+	err = tx.QueryRow(ctx, `
+		SELECT pull_request_id FROM pull_requests WHERE pull_request_id = $1 AND org_id = $2 FOR UPDATE
+	`, prID, tenant.OrgFromContext(ctx)).Scan(&locked)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrNotFound
+		}
+		return 0, err
+	}
+
+	var round int
+	err = tx.QueryRow(ctx, `
+		INSERT INTO review_rounds (org_id, pull_request_id, round)
+		SELECT $2, $1, COALESCE(MAX(round), 0) + 1 FROM review_rounds WHERE pull_request_id = $1 AND org_id = $2
+		RETURNING round
+	`, prID, tenant.OrgFromContext(ctx)).Scan(&round)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE pull_request_reviewers
+		SET verdict = NULL, verdict_at = NULL, assigned_at = CURRENT_TIMESTAMP, overdue_at = NULL
+		WHERE pull_request_id = $1 AND org_id = $2
+	`, prID, tenant.OrgFromContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+
+	return round, tx.Commit(ctx)
+}
+
+// раунды ревью PR с историей итогов
+func (r *Repository) GetReviewRounds(ctx context.Context, prID string) ([]models.ReviewRound, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT round, started_at FROM review_rounds
+		WHERE pull_request_id = $1 AND org_id = $2
+		ORDER BY round
+	`, prID, tenant.OrgFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rounds := []models.ReviewRound{}
+	index := map[int]int{}
+	for rows.Next() {
+		round := models.ReviewRound{Verdicts: []models.ReviewVerdict{}}
+		if err := rows.Scan(&round.Round, &round.StartedAt); err != nil {
+			return nil, err
+		}
+		index[round.Round] = len(rounds)
+		rounds = append(rounds, round)
+	}
+
+	rows, err = r.conn(ctx).Query(ctx, `
+		SELECT round, user_id, verdict, requested_at, submitted_at FROM review_verdicts
+		WHERE pull_request_id = $1 AND org_id = $2
+		ORDER BY submitted_at, id
+	`, prID, tenant.OrgFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var round int
+		var v models.ReviewVerdict
+		if err := rows.Scan(&round, &v.UserID, &v.Verdict, &v.RequestedAt, &v.SubmittedAt); err != nil {
+			return nil, err
+		}
+		if i, ok := index[round]; ok {
+			rounds[i].Verdicts = append(rounds[i].Verdicts, v)
+		}
+	}
+
+	return rounds, nil
+}
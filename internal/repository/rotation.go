@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"pr-reviewer-service/internal/tenant"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// LockTeamRotation блокирует строку команды до конца транзакции, чтобы выбор
+// по очереди не выполнялся параллельно несколькими запросами или экземплярами сервиса.
+// FOR NO KEY UPDATE не мешает вставкам, которые ссылаются на команду (участники, отметки ротации):
+// они проверяют внешний ключ блокировкой FOR KEY SHARE. Вызывать нужно внутри WithTx
+func (r *Repository) LockTeamRotation(ctx context.Context, teamName string) error {
+	var locked string
+	err := r.conn(ctx).QueryRow(ctx, `
+		SELECT team_name FROM teams WHERE team_name = $1 AND org_id = $2 FOR NO KEY UPDATE
+	`, teamName, tenant.OrgFromContext(ctx)).Scan(&locked)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// LockTeamRotations блокирует строки нескольких команд в порядке имен: при одинаковом порядке
+// две транзакции, которым нужны одни и те же команды, не могут заблокировать друг друга.
+// Вызывать нужно внутри WithTx
+func (r *Repository) LockTeamRotations(ctx context.Context, teamNames []string) error {
+	_, err := r.conn(ctx).Exec(ctx, `
+		SELECT team_name FROM teams
+		WHERE team_name = ANY($1) AND org_id = $2
+		ORDER BY team_name
+		FOR NO KEY UPDATE
+	`, teamNames, tenant.OrgFromContext(ctx))
+	return err
+}
+
+// пользователи, которые уже были выбраны в текущем круге ротации команды
+func (r *Repository) GetRotationPicks(ctx context.Context, teamName string) (map[string]bool, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT user_id FROM team_rotation_picks WHERE team_name = $1 AND org_id = $2
+	`, teamName, tenant.OrgFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	picked := make(map[string]bool)
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		picked[userID] = true
+	}
+
+	return picked, nil
+}
+
+func (r *Repository) AddRotationPicks(ctx context.Context, teamName string, userIDs []string) error {
+	_, err := r.conn(ctx).Exec(ctx, `
+		INSERT INTO team_rotation_picks (org_id, team_name, user_id)
+		SELECT $3, $1, unnest($2::varchar[])
+		ON CONFLICT (org_id, team_name, user_id) DO NOTHING
+	`, teamName, userIDs, tenant.OrgFromContext(ctx))
+	return err
+}
+
+// начинает новый круг ротации команды для пользователей из списка
+func (r *Repository) ResetRotation(ctx context.Context, teamName string, userIDs []string) error {
+	_, err := r.conn(ctx).Exec(ctx, `
+		DELETE FROM team_rotation_picks WHERE team_name = $1 AND user_id = ANY($2) AND org_id = $3
+	`, teamName, userIDs, tenant.OrgFromContext(ctx))
+	return err
+}
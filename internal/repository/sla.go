@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+
+	"pr-reviewer-service/internal/models"
+	"pr-reviewer-service/internal/tenant"
+)
+
+// TryLockReviewSLA берет advisory lock проверки SLA до конца транзакции. false - проверку
+// уже выполняет другой экземпляр сервиса. Вызывать нужно внутри WithTx
+func (r *Repository) TryLockReviewSLA(ctx context.Context) (bool, error) {
+	var locked bool
+	err := r.conn(ctx).QueryRow(ctx, `
+		SELECT pg_try_advisory_xact_lock(hashtext('review_sla_check'))
+	`).Scan(&locked)
+	return locked, err
+}
+
+// GetReviewAssignmentsPastSLA возвращает еще не ответивших ревьюверов открытых PR, с назначения
+// которых прошло не меньше review_sla_hours команды автора. Рабочие часы меньше календарных, поэтому
+// окончательно просрочку по расписанию ревьювера определяет сервис. Проверка фоновая, поэтому
+// назначения возвращаются по всем организациям вместе с организацией PR
+func (r *Repository) GetReviewAssignmentsPastSLA(ctx context.Context) ([]models.ReviewAssignment, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT pr.org_id, prr.pull_request_id, prr.user_id, prr.assigned_at, prr.overdue_at, t.review_sla_hours, t.sla_grace_hours
+		FROM pull_request_reviewers prr
+		JOIN pull_requests pr ON pr.org_id = prr.org_id AND pr.pull_request_id = prr.pull_request_id
+		JOIN users author ON author.org_id = pr.org_id AND author.user_id = pr.author_id
+		JOIN teams t ON t.org_id = author.org_id AND t.team_name = author.team_name
+		WHERE pr.status = $1
+		  AND t.review_sla_hours > 0
+		  AND prr.verdict IS NULL
+		  AND prr.assigned_at <= now() - make_interval(hours => t.review_sla_hours)
+		ORDER BY pr.org_id, prr.assigned_at, prr.pull_request_id, prr.user_id
+	`, models.StatusOpen)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	assignments := []models.ReviewAssignment{}
+	for rows.Next() {
+		var a models.ReviewAssignment
+		err := rows.Scan(&a.OrgID, &a.PullRequestID, &a.UserID, &a.AssignedAt, &a.OverdueAt, &a.ReviewSLAHours, &a.SLAGraceHours)
+		if err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, a)
+	}
+
+	return assignments, nil
+}
+
+// MarkReviewOverdue помечает ревью просроченным, ErrNotFound - ревьювер уже снят или помечен
+func (r *Repository) MarkReviewOverdue(ctx context.Context, prID, userID string) error {
+	result, err := r.conn(ctx).Exec(ctx, `
+		UPDATE pull_request_reviewers
+		SET overdue_at = CURRENT_TIMESTAMP
+		WHERE pull_request_id = $1 AND user_id = $2 AND org_id = $3 AND overdue_at IS NULL
+	`, prID, userID, tenant.OrgFromContext(ctx))
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// AddSLAEvent сохраняет событие SLA, заполняя ID и CreatedAt
+func (r *Repository) AddSLAEvent(ctx context.Context, e *models.SLAEvent) error {
+	return r.conn(ctx).QueryRow(ctx, `
+		INSERT INTO review_sla_events (pull_request_id, user_id, kind, replaced_by, org_id)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5)
+		RETURNING id, created_at
+	`, e.PullRequestID, e.UserID, e.Kind, e.ReplacedBy, tenant.OrgFromContext(ctx)).Scan(&e.ID, &e.CreatedAt)
+}
+
+// события SLA PR в порядке их записи
+func (r *Repository) GetSLAEvents(ctx context.Context, prID string) ([]models.SLAEvent, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT id, pull_request_id, user_id, kind, COALESCE(replaced_by, ''), created_at
+		FROM review_sla_events
+		WHERE pull_request_id = $1 AND org_id = $2
+		ORDER BY created_at, id
+	`, prID, tenant.OrgFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []models.SLAEvent{}
+	for rows.Next() {
+		var e models.SLAEvent
+		if err := rows.Scan(&e.ID, &e.PullRequestID, &e.UserID, &e.Kind, &e.ReplacedBy, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+
+	return events, nil
+}
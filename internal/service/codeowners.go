@@ -0,0 +1,133 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"pr-reviewer-service/internal/models"
+)
+
+// правило CODEOWNERS с заранее скомпилированным шаблоном
+type codeownersRule struct {
+	models.CodeownersRule
+	re *regexp.Regexp
+}
+
+// parseCodeowners разбирает файл в синтаксисе CODEOWNERS: каждая строка - шаблон пути
+// и список владельцев (user_id, допускается префикс @). Пустые строки и комментарии (#) пропускаются
+func parseCodeowners(content string) ([]codeownersRule, error) {
+	rules := []codeownersRule{}
+
+	for i, line := range strings.Split(content, "\n") {
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		re, err := compileOwnershipPattern(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid pattern %q: %w", i+1, fields[0], err)
+		}
+
+		owners := make([]string, 0, len(fields)-1)
+		for _, owner := range fields[1:] {
+			owner = strings.TrimPrefix(owner, "@")
+			if owner == "" {
+				return nil, fmt.Errorf("line %d: empty owner", i+1)
+			}
+			owners = append(owners, owner)
+		}
+
+		rules = append(rules, codeownersRule{
+			CodeownersRule: models.CodeownersRule{Pattern: fields[0], Owners: owners, Line: i + 1},
+			re:             re,
+		})
+	}
+
+	return rules, nil
+}
+
+// compileOwnershipPattern переводит шаблон CODEOWNERS (gitignore-подобный) в регулярное выражение:
+//   - "*" - любые символы внутри одного сегмента пути, "?" - один символ, "**" - любое число сегментов;
+//   - шаблон со слешем в начале или в середине привязан к корню репозитория, без слеша - совпадает на любой глубине;
+//   - шаблон со слешем в конце совпадает только с содержимым каталога;
+//   - шаблон без масок в последнем сегменте совпадает и с файлом, и со всем содержимым каталога
+func compileOwnershipPattern(pattern string) (*regexp.Regexp, error) {
+	dirOnly := strings.HasSuffix(pattern, "/")
+	p := strings.TrimSuffix(pattern, "/")
+	anchored := strings.Contains(p, "/")
+	p = strings.TrimPrefix(p, "/")
+	if p == "" {
+		return nil, fmt.Errorf("empty pattern")
+	}
+
+	var b strings.Builder
+	if anchored {
+		b.WriteString("^")
+	} else {
+		b.WriteString("^(?:.*/)?")
+	}
+
+	for i := 0; i < len(p); i++ {
+		switch {
+		case strings.HasPrefix(p[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(p[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case p[i] == '*':
+			b.WriteString("[^/]*")
+		case p[i] == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(p[i])))
+		}
+	}
+
+	lastSegment := p[strings.LastIndex(p, "/")+1:]
+	switch {
+	case dirOnly:
+		b.WriteString("/.*$")
+	case strings.ContainsAny(lastSegment, "*?"):
+		b.WriteString("$")
+	default:
+		b.WriteString("(?:/.*)?$")
+	}
+
+	return regexp.Compile(b.String())
+}
+
+// matchOwnership для каждого измененного файла находит последнее подходящее правило
+// (как в GitHub, более поздние правила перекрывают ранние). Файлы без владельцев пропускаются
+func matchOwnership(rules []codeownersRule, files []string) []models.OwnershipMatch {
+	matches := []models.OwnershipMatch{}
+
+	for _, file := range files {
+		path := strings.TrimPrefix(strings.TrimPrefix(file, "./"), "/")
+		if path == "" {
+			continue
+		}
+
+		for i := len(rules) - 1; i >= 0; i-- {
+			if !rules[i].re.MatchString(path) {
+				continue
+			}
+			if len(rules[i].Owners) > 0 {
+				matches = append(matches, models.OwnershipMatch{
+					Pattern: rules[i].Pattern,
+					Line:    rules[i].Line,
+					Path:    file,
+					Owners:  rules[i].Owners,
+				})
+			}
+			break
+		}
+	}
+
+	return matches
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"testing"
+)
+
+func TestCompileOwnershipPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{pattern: "*", path: "main.go", want: true},
+		{pattern: "*", path: "internal/service/service.go", want: true},
+		{pattern: "*.go", path: "internal/service/service.go", want: true},
+		{pattern: "*.go", path: "README.md", want: false},
+		{pattern: "/migrations/", path: "migrations/001_init_schema.up.sql", want: true},
+		{pattern: "/migrations/", path: "internal/migrations/x.sql", want: false},
+		{pattern: "migrations/", path: "internal/migrations/x.sql", want: true},
+		{pattern: "docs/", path: "docs/api/index.md", want: true},
+		{pattern: "docs/*", path: "docs/index.md", want: true},
+		{pattern: "docs/*", path: "docs/api/index.md", want: false},
+		{pattern: "apps", path: "src/apps/web/main.ts", want: true},
+		{pattern: "/internal/service", path: "internal/service/service.go", want: true},
+		{pattern: "/internal/service", path: "internal/servicex/a.go", want: false},
+		{pattern: "**/logs", path: "build/deep/logs/a.log", want: true},
+		{pattern: "/build/**/*.log", path: "build/a/b/c.log", want: true},
+		{pattern: "/build/**/*.log", path: "build/c.log", want: true},
+		{pattern: "file?.txt", path: "dir/file1.txt", want: true},
+		{pattern: "file?.txt", path: "dir/file12.txt", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			re, err := compileOwnershipPattern(tt.pattern)
+			if err != nil {
+				t.Fatalf("compileOwnershipPattern(%q) error: %v", tt.pattern, err)
+			}
+			if got := re.MatchString(tt.path); got != tt.want {
+				t.Errorf("pattern %q on %q = %v, want %v (regexp %s)", tt.pattern, tt.path, got, tt.want, re)
+			}
+		})
+	}
+}
+
+func TestParseCodeowners(t *testing.T) {
+	content := `
+# владельцы по умолчанию
+*            @u1
+
+/migrations/ u2 @u3   # база данных
+*.md
+`
+	rules, err := parseCodeowners(content)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rules) != 3 {
+		t.Fatalf("expected 3 rules, got %d", len(rules))
+	}
+
+	if rules[1].Pattern != "/migrations/" || rules[1].Line != 5 {
+		t.Errorf("unexpected second rule: %+v", rules[1].CodeownersRule)
+	}
+	if len(rules[1].Owners) != 2 || rules[1].Owners[0] != "u2" || rules[1].Owners[1] != "u3" {
+		t.Errorf("expected owners [u2 u3] without @, got %v", rules[1].Owners)
+	}
+	if len(rules[2].Owners) != 0 {
+		t.Errorf("expected rule without owners, got %v", rules[2].Owners)
+	}
+
+	if _, err := parseCodeowners("/ u1"); err == nil {
+		t.Error("expected error for empty pattern")
+	}
+	if _, err := parseCodeowners("*.go @"); err == nil {
+		t.Error("expected error for empty owner")
+	}
+}
+
+func TestMatchOwnership(t *testing.T) {
+	rules, err := parseCodeowners(`
+*             u1
+/migrations/  u2
+*.md
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	matches := matchOwnership(rules, []string{
+		"migrations/002.up.sql",
+		"cmd/server/main.go",
+		"README.md",
+	})
+
+	// README.md совпадает с последним правилом без владельцев и пропускается
+	if len(matches) != 2 {
+		t.Fatalf("expected 2 matches, got %d: %+v", len(matches), matches)
+	}
+	if matches[0].Pattern != "/migrations/" || matches[0].Owners[0] != "u2" || matches[0].Line != 3 {
+		t.Errorf("expected later /migrations/ rule to win, got %+v", matches[0])
+	}
+	if matches[1].Pattern != "*" || matches[1].Path != "cmd/server/main.go" {
+		t.Errorf("expected default rule for main.go, got %+v", matches[1])
+	}
+}
@@ -0,0 +1,359 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"pr-reviewer-service/internal/models"
+	"pr-reviewer-service/internal/repository"
+)
+
+// DeactivateTeamUsers деактивирует пользователей команды и в той же транзакции заменяет их
+// на всех открытых PR. Число запросов к базе не зависит от числа пользователей и PR
+func (s *Service) DeactivateTeamUsers(ctx context.Context, req models.DeactivateUsersRequest) (*models.DeactivationReport, error) {
+	ids := uniqueIDs(req.UserIDs)
+	if req.TeamName == "" || len(ids) == 0 {
+		return nil, fmt.Errorf("%s: team_name and user_ids are required", models.ErrCodeBadRequest)
+	}
+
+	settings, err := s.repo.GetTeamSettings(ctx, req.TeamName)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("%s: team not found", models.ErrCodeNotFound)
+		}
+		return nil, err
+	}
+
+	report := &models.DeactivationReport{
+		TeamName:         req.TeamName,
+		DeactivatedUsers: ids,
+		PullRequests:     []models.PRReassignment{},
+	}
+
+	err = s.repo.WithTx(ctx, func(ctx context.Context) error {
+		deactivated, err := s.repo.DeactivateTeamUsers(ctx, req.TeamName, ids)
+		if err != nil {
+			return err
+		}
+		if len(deactivated) != len(ids) {
+			return fmt.Errorf("%s: users not found in team %s: %s",
+				models.ErrCodeNotFound, req.TeamName, strings.Join(missingIDs(ids, deactivated), ", "))
+		}
+
+		prs, err := s.repo.GetOpenPRsReviewedBy(ctx, ids)
+		if err != nil {
+			return err
+		}
+		if len(prs) == 0 {
+			return nil
+		}
+
+		bulk, err := s.newBulkReassignment(ctx, req.TeamName, settings, deactivated, prs)
+		if err != nil {
+			return err
+		}
+
+		prIDs := make([]string, 0, len(prs))
+		added := make(map[string][]models.ReviewerInfo, len(prs))
+		explanations := make([]*models.AssignmentExplanation, 0, len(prs))
+		for _, pr := range prs {
+			trace := newSelectionTrace(bulk.strategy.Name())
+			trace.addTeam(req.TeamName)
+
+			replacements, reviewers, err := bulk.replace(ctx, trace, pr)
+			if err != nil {
+				return err
+			}
+			for userID, reason := range bulk.unavailable {
+				trace.exclude(userID, reason)
+			}
+
+			prIDs = append(prIDs, pr.PullRequestID)
+			added[pr.PullRequestID] = reviewers
+			explanations = append(explanations, trace.explain(pr.PullRequestID, models.AssignmentReassign, reviewerIDs(reviewers)))
+			report.PullRequests = append(report.PullRequests, models.PRReassignment{
+				PullRequestID: pr.PullRequestID,
+				Replacements:  replacements,
+			})
+		}
+
+		if err := s.repo.ReplaceReviewers(ctx, prIDs, ids, added); err != nil {
+			return err
+		}
+		if bulk.rotation != nil {
+			if err := s.saveRotation(ctx, req.TeamName, bulk); err != nil {
+				return err
+			}
+		}
+		return s.repo.AddAssignmentExplanations(ctx, explanations)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Deactivated %d users in team %s, reassigned reviewers on %d open PRs",
+		len(ids), req.TeamName, len(report.PullRequests))
+
+	return report, nil
+}
+
+// массовая замена ревьюверов: все данные загружаются один раз на пакет,
+// нагрузка кандидатов и круг round_robin обновляются в памяти по мере назначений
+type bulkReassignment struct {
+	// стратегия команды; она получает только данные пакета и к базе не обращается
+	strategy ReviewerStrategy
+	// круг round_robin команды, nil для остальных стратегий
+	rotation map[string]bool
+	removed  map[string]bool
+	// активные участники команды - кандидаты на замену
+	pool []models.User
+	load map[string]int
+	// группа доступности участника пула на момент начала пакета (см. preferAvailable)
+	availability map[string]int
+	// снятые и оставшиеся ревьюверы затронутых PR - для проверки требования senior
+	users         map[string]models.User
+	requireSenior bool
+	// неактивные и отсутствующие участники команды - только для объяснений
+	unavailable map[string]string
+}
+
+func (s *Service) newBulkReassignment(
+	ctx context.Context, teamName string, settings *models.TeamSettings, removed []models.User, prs []models.PullRequest,
+) (*bulkReassignment, error) {
+	pool, err := s.repo.GetActiveTeamMembers(ctx, teamName, "")
+	if err != nil {
+		return nil, err
+	}
+	load, err := s.repo.GetOpenReviewCounts(ctx, userIDs(pool))
+	if err != nil {
+		return nil, err
+	}
+	unavailable, err := s.repo.GetUnavailableMembers(ctx, []string{teamName})
+	if err != nil {
+		return nil, err
+	}
+
+	strategy := s.teamStrategy(teamName, settings.AssignmentStrategy)
+	b := newBulkState(strategy, removed, pool, load, time.Now())
+	b.unavailable = unavailable
+
+	if strategy.Name() == models.StrategyRoundRobin {
+		if err := s.repo.LockTeamRotation(ctx, teamName); err != nil {
+			return nil, err
+		}
+		if b.rotation, err = s.repo.GetRotationPicks(ctx, teamName); err != nil {
+			return nil, err
+		}
+	}
+
+	if settings.RequireSenior {
+		b.requireSenior = true
+		var reviewerIDs []string
+		for _, pr := range prs {
+			for _, id := range pr.AssignedReviewers {
+				if !b.removed[id] {
+					reviewerIDs = append(reviewerIDs, id)
+				}
+			}
+		}
+		reviewers, err := s.repo.GetUsers(ctx, uniqueIDs(reviewerIDs))
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range reviewers {
+			b.users[u.UserID] = u
+		}
+	}
+
+	return b, nil
+}
+
+// saveRotation сохраняет круг round_robin после пакета: отметки участников пула заменяются
+// состоянием из памяти, отметки остальных участников не меняются
+func (s *Service) saveRotation(ctx context.Context, teamName string, b *bulkReassignment) error {
+	if err := s.repo.ResetRotation(ctx, teamName, userIDs(b.pool)); err != nil {
+		return err
+	}
+	picked := make([]string, 0, len(b.rotation))
+	for id := range b.rotation {
+		picked = append(picked, id)
+	}
+	return s.repo.AddRotationPicks(ctx, teamName, picked)
+}
+
+func newBulkState(strategy ReviewerStrategy, removed, pool []models.User, load map[string]int, now time.Time) *bulkReassignment {
+	b := &bulkReassignment{
+		strategy: strategy,
+		removed:  make(map[string]bool, len(removed)),
+		pool:     pool,
+		load:     load,
+		users:    make(map[string]models.User, len(removed)),
+	}
+	for _, u := range removed {
+		b.removed[u.UserID] = true
+		b.users[u.UserID] = u
+	}
+
+	// рабочие окна считаются один раз для всего пакета
+	b.availability = make(map[string]int, len(pool))
+	for rank, group := range preferAvailable([][]models.User{pool}, now) {
+		for _, u := range group {
+			b.availability[u.UserID] = rank
+		}
+	}
+
+	return b
+}
+
+// replace подбирает замену каждому снятому ревьюверу PR стратегией команды, отдавая приоритет
+// senior (если PR теряет единственного senior), нужным тегам и рабочему времени.
+// Если кандидатов нет, место остается свободным. Возвращает отчет и новых ревьюверов
+func (b *bulkReassignment) replace(
+	ctx context.Context, trace *selectionTrace, pr models.PullRequest,
+) ([]models.ReviewerReplacement, []models.ReviewerInfo, error) {
+	trace.consider(b.pool)
+	trace.exclude(pr.AuthorID, models.ExclusionAuthor)
+
+	taken := map[string]bool{pr.AuthorID: true}
+	var kept, lost []models.User
+	for _, id := range pr.AssignedReviewers {
+		taken[id] = true
+		if b.removed[id] {
+			trace.exclude(id, models.ExclusionReplaced)
+			lost = append(lost, b.users[id])
+		} else {
+			trace.exclude(id, models.ExclusionAlreadyAssigned)
+			kept = append(kept, b.users[id])
+		}
+	}
+	needSenior := b.requireSenior && containsSenior(lost) && !containsSenior(kept)
+
+	replacements := []models.ReviewerReplacement{}
+	added := []models.ReviewerInfo{}
+	for _, reviewer := range pr.Reviewers {
+		if !b.removed[reviewer.UserID] {
+			continue
+		}
+
+		replacement := models.ReviewerReplacement{OldUserID: reviewer.UserID}
+		picked, ok, err := b.pick(ctx, trace, taken, pr.RequiredTags, needSenior)
+		if err != nil {
+			return nil, nil, err
+		}
+		if ok {
+			replacement.NewUserID = picked.UserID
+			// замена занимает то же место, в том числе место команды-партнера
+			added = append(added, models.ReviewerInfo{UserID: picked.UserID, FallbackTeam: reviewer.FallbackTeam})
+			b.load[picked.UserID]++
+			taken[picked.UserID] = true
+			needSenior = needSenior && !isSenior(picked)
+		}
+		replacements = append(replacements, replacement)
+	}
+
+	return replacements, added, nil
+}
+
+// группы доступности из preferAvailable: в рабочем времени, ближайшее начало, остальные
+const availabilityGroups = 3
+
+// pick выбирает одного кандидата из пула (кроме taken) за одно решение стратегии, как selectReviewers:
+// группы приоритета (senior, теги), внутри каждой - группы доступности на момент начала пакета.
+// Группы собираются за один проход по пулу: пакет из сотен PR не должен копировать пул на каждом шаге
+func (b *bulkReassignment) pick(
+	ctx context.Context, trace *selectionTrace, taken map[string]bool, requiredTags []string, needSenior bool,
+) (models.User, bool, error) {
+	// номер группы каждого кандидата, -1 - кандидат не рассматривается
+	groupOf := make([]int, len(b.pool))
+	sizes := make([]int, 4*availabilityGroups)
+	for i, u := range b.pool {
+		groupOf[i] = -1
+		if taken[u.UserID] {
+			continue
+		}
+		if atCapacity(u, b.load) {
+			trace.exclude(u.UserID, models.ExclusionAtCapacity)
+			continue
+		}
+
+		// тот же порядок, что дают preferBy(isSenior) и затем preferTags
+		priority := 0
+		if needSenior && !isSenior(u) {
+			priority += 2
+		}
+		if !hasAllTags(u.Tags, requiredTags) {
+			priority++
+		}
+		groupOf[i] = priority*availabilityGroups + b.availability[u.UserID]
+		sizes[groupOf[i]]++
+	}
+
+	// все группы занимают один массив
+	backing := make([]models.User, 0, len(b.pool))
+	ranked := make([][]models.User, len(sizes))
+	for g, size := range sizes {
+		ranked[g] = backing[len(backing) : len(backing) : len(backing)+size]
+		backing = backing[:len(backing)+size]
+	}
+	for i, g := range groupOf {
+		if g >= 0 {
+			ranked[g] = append(ranked[g], b.pool[i])
+		}
+	}
+
+	picked, err := b.strategy.Select(ctx, SelectionRequest{
+		Groups:   ranked,
+		Load:     b.load,
+		Count:    1,
+		Rand:     trace.rng,
+		Rotation: b.rotation,
+	})
+	if err != nil || len(picked) == 0 {
+		return models.User{}, false, err
+	}
+	return picked[0], true, nil
+}
+
+// uniqueIDs убирает пустые и повторяющиеся идентификаторы, сохраняя порядок
+func uniqueIDs(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	result := []string{}
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id != "" && !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+func missingIDs(ids []string, found []models.User) []string {
+	present := toSet(userIDs(found))
+	missing := []string{}
+	for _, id := range ids {
+		if !present[id] {
+			missing = append(missing, id)
+		}
+	}
+	return missing
+}
+
+func toSet(ids []string) map[string]bool {
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
+
+func reviewerIDs(reviewers []models.ReviewerInfo) []string {
+	ids := make([]string, len(reviewers))
+	for i, r := range reviewers {
+		ids[i] = r.UserID
+	}
+	return ids
+}
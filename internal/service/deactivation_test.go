@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"pr-reviewer-service/internal/models"
+)
+
+func TestBulkReassignmentReplace(t *testing.T) {
+	intPtr := func(v int) *int { return &v }
+	ctx := context.Background()
+	leastLoaded := defaultStrategies(nil)[models.StrategyLeastLoaded]
+	now := time.Date(2025, 10, 22, 12, 0, 0, 0, time.UTC)
+	removed := []models.User{
+		{UserID: "u1", TeamName: "backend", Level: models.LevelSenior},
+		{UserID: "u2", TeamName: "backend"},
+	}
+	pool := []models.User{
+		{UserID: "u3", TeamName: "backend", IsActive: true},
+		{UserID: "u4", TeamName: "backend", IsActive: true},
+		{UserID: "u5", TeamName: "backend", IsActive: true, Level: models.LevelSenior, MaxOpenReviews: intPtr(1)},
+	}
+
+	t.Run("нагрузка учитывает назначения пакета", func(t *testing.T) {
+		b := newBulkState(leastLoaded, removed, pool, map[string]int{"u5": 1}, now)
+		prs := []models.PullRequest{
+			{PullRequestID: "pr-1", AuthorID: "u9", AssignedReviewers: []string{"u2"}, Reviewers: []models.ReviewerInfo{{UserID: "u2"}}},
+			{PullRequestID: "pr-2", AuthorID: "u9", AssignedReviewers: []string{"u2"}, Reviewers: []models.ReviewerInfo{{UserID: "u2"}}},
+		}
+
+		var picked []string
+		for _, pr := range prs {
+			replacements, _, err := b.replace(ctx, newSelectionTrace(models.StrategyLeastLoaded), pr)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			picked = append(picked, replacements[0].NewUserID)
+		}
+		if picked[0] == picked[1] || picked[0] == "u5" || picked[1] == "u5" {
+			t.Errorf("expected u3 and u4 once each (u5 at capacity), got %v", picked)
+		}
+	})
+
+	t.Run("без кандидатов место остается свободным", func(t *testing.T) {
+		b := newBulkState(leastLoaded, removed, pool, map[string]int{"u5": 1}, now)
+		pr := models.PullRequest{
+			PullRequestID:     "pr-1",
+			AuthorID:          "u3",
+			AssignedReviewers: []string{"u4", "u1", "u2"},
+			Reviewers:         []models.ReviewerInfo{{UserID: "u4"}, {UserID: "u1"}, {UserID: "u2"}},
+		}
+
+		trace := newSelectionTrace(models.StrategyLeastLoaded)
+		replacements, added, err := b.replace(ctx, trace, pr)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := []models.ReviewerReplacement{{OldUserID: "u1"}, {OldUserID: "u2"}}
+		if !reflect.DeepEqual(replacements, want) || len(added) != 0 {
+			t.Errorf("replace() = %v, %v, want %v and no reviewers", replacements, added, want)
+		}
+		if trace.excluded["u5"] != models.ExclusionAtCapacity {
+			t.Errorf("expected u5 excluded as at_capacity, got %q", trace.excluded["u5"])
+		}
+	})
+
+	t.Run("замена единственного senior - senior, место команды-партнера сохраняется", func(t *testing.T) {
+		b := newBulkState(leastLoaded, removed, pool, map[string]int{}, now)
+		b.requireSenior = true
+		pr := models.PullRequest{
+			PullRequestID:     "pr-1",
+			AuthorID:          "u9",
+			AssignedReviewers: []string{"u1"},
+			Reviewers:         []models.ReviewerInfo{{UserID: "u1", FallbackTeam: "backend"}},
+		}
+
+		replacements, added, err := b.replace(ctx, newSelectionTrace(models.StrategyLeastLoaded), pr)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if replacements[0].NewUserID != "u5" {
+			t.Errorf("expected senior u5 as replacement, got %v", replacements)
+		}
+		if want := []models.ReviewerInfo{{UserID: "u5", FallbackTeam: "backend"}}; !reflect.DeepEqual(added, want) {
+			t.Errorf("added = %v, want %v", added, want)
+		}
+		if b.load["u5"] != 1 {
+			t.Errorf("expected u5 load to grow to 1, got %d", b.load["u5"])
+		}
+	})
+}
+
+func TestBulkReassignmentReplace_RoundRobin(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 10, 22, 12, 0, 0, 0, time.UTC)
+	removed := []models.User{{UserID: "u1", TeamName: "backend"}}
+	pool := []models.User{
+		{UserID: "u2", TeamName: "backend", IsActive: true},
+		{UserID: "u3", TeamName: "backend", IsActive: true},
+		{UserID: "u4", TeamName: "backend", IsActive: true},
+	}
+
+	// u2 и u3 уже выбраны в текущем круге, нагрузка для очереди не важна
+	b := newBulkState(defaultStrategies(nil)[models.StrategyRoundRobin], removed, pool, map[string]int{"u4": 5}, now)
+	b.rotation = map[string]bool{"u2": true, "u3": true}
+
+	var picked []string
+	for i := 0; i < 4; i++ {
+		pr := models.PullRequest{
+			PullRequestID:     fmt.Sprintf("pr-%d", i),
+			AuthorID:          "u9",
+			AssignedReviewers: []string{"u1"},
+			Reviewers:         []models.ReviewerInfo{{UserID: "u1"}},
+		}
+		replacements, _, err := b.replace(ctx, newSelectionTrace(models.StrategyRoundRobin), pr)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		picked = append(picked, replacements[0].NewUserID)
+	}
+
+	if picked[0] != "u4" {
+		t.Errorf("expected u4 (not picked in current round) first, got %v", picked)
+	}
+	// затем новый круг: каждый участник пула по одному разу
+	if round := toSet(picked[1:]); len(round) != len(pool) {
+		t.Errorf("expected each of u2, u3, u4 once in the new round, got %v", picked)
+	}
+	if len(b.rotation) != len(pool) {
+		t.Errorf("expected the whole pool picked in the new round, got %v", b.rotation)
+	}
+}
+
+// требование: деактивация в команде из ~200 участников с несколькими сотнями PR - заметно меньше 100 мс.
+// Число запросов к базе от числа пользователей и PR не зависит, поэтому время определяется подбором
+// замен в памяти: здесь 50 участников снимаются с 300 PR, на каждом - два снятых ревьювера
+func BenchmarkBulkReassignment(b *testing.B) {
+	ctx := context.Background()
+	now := time.Date(2025, 10, 22, 12, 0, 0, 0, time.UTC)
+	office := &models.WorkingHours{Start: "09:00", End: "18:00", Days: []int{1, 2, 3, 4, 5}}
+
+	const teamSize, deactivated, prCount = 200, 50, 300
+	var removed, pool []models.User
+	for i := 0; i < teamSize; i++ {
+		u := models.User{UserID: fmt.Sprintf("u%d", i), TeamName: "backend", IsActive: true, Tags: []string{"go"}}
+		if i%3 == 0 {
+			u.Timezone, u.WorkingHours = "Europe/Moscow", office
+		}
+		if i%5 == 0 {
+			u.Level = models.LevelSenior
+		}
+		if i < deactivated {
+			removed = append(removed, u)
+		} else {
+			pool = append(pool, u)
+		}
+	}
+	prs := make([]models.PullRequest, prCount)
+	for i := range prs {
+		first, second := removed[i%deactivated], removed[(i*7+1)%deactivated]
+		if first.UserID == second.UserID {
+			second = pool[i%len(pool)]
+		}
+		prs[i] = models.PullRequest{
+			PullRequestID:     fmt.Sprintf("pr-%d", i),
+			AuthorID:          pool[(i*3)%len(pool)].UserID,
+			AssignedReviewers: []string{first.UserID, second.UserID},
+			Reviewers:         []models.ReviewerInfo{{UserID: first.UserID}, {UserID: second.UserID}},
+			RequiredTags:      []string{"go"},
+		}
+	}
+
+	for _, name := range []string{models.StrategyLeastLoaded, models.StrategyRoundRobin} {
+		strategy := defaultStrategies(nil)[name]
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				bulk := newBulkState(strategy, removed, pool, map[string]int{}, now)
+				bulk.requireSenior = true
+				if name == models.StrategyRoundRobin {
+					bulk.rotation = map[string]bool{}
+				}
+				for _, pr := range prs {
+					if _, _, err := bulk.replace(ctx, newSelectionTrace(name), pr); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestUniqueIDs(t *testing.T) {
+	got := uniqueIDs([]string{"u2", " u1", "", "u2", "u1 "})
+	if want := []string{"u2", "u1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("uniqueIDs() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"pr-reviewer-service/internal/models"
+	"pr-reviewer-service/internal/repository"
+)
+
+// максимальная длина причины отказа
+const maxDeclineReasonLength = 1000
+
+// DeclineReview снимает ревьювера с PR по его собственному отказу и подбирает замену
+// так же, как ReassignReviewer. Если заменить некем, место ревьювера остается свободным
+func (s *Service) DeclineReview(ctx context.Context, req models.DeclineRequest) (*models.PullRequest, *models.ReviewDecline, error) {
+	reason := strings.TrimSpace(req.Reason)
+	if req.PullRequestID == "" || req.UserID == "" || reason == "" {
+		return nil, nil, fmt.Errorf("%s: pull_request_id, user_id and reason are required", models.ErrCodeBadRequest)
+	}
+	if len([]rune(reason)) > maxDeclineReasonLength {
+		return nil, nil, fmt.Errorf("%s: reason must be at most %d characters", models.ErrCodeBadRequest, maxDeclineReasonLength)
+	}
+
+	pr, err := s.getOpenPR(ctx, req.PullRequestID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	isAssigned, err := s.repo.IsReviewerAssigned(ctx, pr.PullRequestID, req.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !isAssigned {
+		return nil, nil, fmt.Errorf("%s: reviewer is not assigned to this PR", models.ErrCodeNotAssigned)
+	}
+
+	reviewer, err := s.repo.GetUser(ctx, req.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil, fmt.Errorf("%s: user not found", models.ErrCodeNotFound)
+		}
+		return nil, nil, err
+	}
+
+	decline := &models.ReviewDecline{
+		PullRequestID: pr.PullRequestID,
+		UserID:        reviewer.UserID,
+		Reason:        reason,
+	}
+	err = s.repo.WithTx(ctx, func(ctx context.Context) error {
+		newReviewerID, err := s.replaceReviewer(ctx, pr, reviewer, models.AssignmentDecline)
+		switch {
+		case err == nil:
+			decline.ReplacedBy = newReviewerID
+		case hasErrorCode(err, models.ErrCodeNoCandidate), hasErrorCode(err, models.ErrCodeNoCapacity):
+			s.logger.Warn("No replacement for declined reviewer %s on PR %s, leaving the slot empty: %v",
+				reviewer.UserID, pr.PullRequestID, err)
+			if err := s.repo.RemoveReviewer(ctx, pr.PullRequestID, reviewer.UserID); err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+
+		return s.repo.AddReviewDecline(ctx, decline)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.logger.Info("Reviewer %s declined PR %s, replaced by %q", reviewer.UserID, pr.PullRequestID, decline.ReplacedBy)
+
+	updatedPR, err := s.getAssignedPR(ctx, pr.PullRequestID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return updatedPR, decline, nil
+}
+
+// declineRate - доля отказов среди всех назначений пользователя: текущих ревью и тех, от которых он отказался
+func declineRate(declines, reviews int) float64 {
+	if declines == 0 {
+		return 0
+	}
+	return float64(declines) / float64(declines+reviews)
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+
+	"pr-reviewer-service/internal/models"
+)
+
+// selectionTrace накапливает сведения о выборе ревьюверов за все его этапы,
+// из них строится объяснение назначения
+type selectionTrace struct {
+	strategy string
+	seed     int64
+	rng      *rand.Rand
+	// все рассмотренные кандидаты в порядке появления
+	pool   []string
+	inPool map[string]bool
+	// причина исключения кандидата, сохраняется первая
+	excluded map[string]string
+	// команды, из которых брались кандидаты: их недоступные участники тоже попадают в объяснение
+	teams []string
+	// пробное назначение: ничего не сохраняется, очередь round_robin не сдвигается
+	dryRun bool
+}
+
+func newSelectionTrace(strategy string) *selectionTrace {
+	seed := rand.Int63()
+	return &selectionTrace{
+		strategy: strategy,
+		seed:     seed,
+		rng:      rand.New(rand.NewSource(seed)),
+		inPool:   make(map[string]bool),
+		excluded: make(map[string]string),
+	}
+}
+
+func (t *selectionTrace) consider(users []models.User) {
+	for _, u := range users {
+		if !t.inPool[u.UserID] {
+			t.inPool[u.UserID] = true
+			t.pool = append(t.pool, u.UserID)
+		}
+	}
+}
+
+func (t *selectionTrace) exclude(userID, reason string) {
+	if _, ok := t.excluded[userID]; !ok {
+		t.excluded[userID] = reason
+	}
+}
+
+func (t *selectionTrace) hasExclusion(reason string) bool {
+	for _, r := range t.excluded {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *selectionTrace) addTeam(teamName string) {
+	for _, name := range t.teams {
+		if name == teamName {
+			return
+		}
+	}
+	t.teams = append(t.teams, teamName)
+}
+
+// explain строит объяснение назначения: выбранные ревьюверы в список исключенных не попадают,
+// даже если на одном из этапов они были пропущены
+func (t *selectionTrace) explain(prID, kind string, selected []string) *models.AssignmentExplanation {
+	isSelected := make(map[string]bool, len(selected))
+	for _, id := range selected {
+		isSelected[id] = true
+	}
+
+	excluded := []models.ExcludedCandidate{}
+	for id, reason := range t.excluded {
+		if !isSelected[id] {
+			excluded = append(excluded, models.ExcludedCandidate{UserID: id, Reason: reason})
+		}
+	}
+	sort.Slice(excluded, func(i, j int) bool { return excluded[i].UserID < excluded[j].UserID })
+
+	pool := append([]string{}, t.pool...)
+	return &models.AssignmentExplanation{
+		PullRequestID: prID,
+		Kind:          kind,
+		Strategy:      t.strategy,
+		Seed:          t.seed,
+		CandidatePool: pool,
+		Excluded:      excluded,
+		Selected:      append([]string{}, selected...),
+	}
+}
+
+// excludeUnavailable дополняет trace недоступными участниками задействованных команд
+func (s *Service) excludeUnavailable(ctx context.Context, trace *selectionTrace) error {
+	unavailable, err := s.repo.GetUnavailableMembers(ctx, trace.teams)
+	if err != nil {
+		return err
+	}
+	for userID, reason := range unavailable {
+		trace.exclude(userID, reason)
+	}
+	return nil
+}
+
+// saveExplanation сохраняет объяснение назначения. Вызывать нужно в транзакции назначения
+func (s *Service) saveExplanation(
+	ctx context.Context, trace *selectionTrace, prID, kind string, selected []string,
+) (*models.AssignmentExplanation, error) {
+	if err := s.excludeUnavailable(ctx, trace); err != nil {
+		return nil, err
+	}
+
+	explanation := trace.explain(prID, kind, selected)
+	if err := s.repo.AddAssignmentExplanation(ctx, explanation); err != nil {
+		return nil, err
+	}
+	return explanation, nil
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"pr-reviewer-service/internal/models"
+)
+
+func TestSelectionTraceExplain(t *testing.T) {
+	trace := newSelectionTrace(models.StrategyRoundRobin)
+	trace.consider([]models.User{{UserID: "u2"}, {UserID: "u3"}, {UserID: "u4"}})
+	trace.consider([]models.User{{UserID: "u3"}, {UserID: "u5"}})
+
+	trace.exclude("u1", models.ExclusionAuthor)
+	trace.exclude("u4", models.ExclusionAtCapacity)
+	// сохраняется первая причина
+	trace.exclude("u4", models.ExclusionInactive)
+	// пропущен на одном этапе, но выбран на другом
+	trace.exclude("u3", models.ExclusionAtCapacity)
+
+	got := trace.explain("pr-1", models.AssignmentInitial, []string{"u2", "u3"})
+
+	if got.Strategy != models.StrategyRoundRobin || got.Seed != trace.seed || got.Kind != models.AssignmentInitial {
+		t.Errorf("unexpected explanation header: %+v", got)
+	}
+	if want := []string{"u2", "u3", "u4", "u5"}; !reflect.DeepEqual(got.CandidatePool, want) {
+		t.Errorf("candidate pool = %v, want %v", got.CandidatePool, want)
+	}
+	wantExcluded := []models.ExcludedCandidate{
+		{UserID: "u1", Reason: models.ExclusionAuthor},
+		{UserID: "u4", Reason: models.ExclusionAtCapacity},
+	}
+	if !reflect.DeepEqual(got.Excluded, wantExcluded) {
+		t.Errorf("excluded = %+v, want %+v", got.Excluded, wantExcluded)
+	}
+	if !trace.hasExclusion(models.ExclusionAtCapacity) || trace.hasExclusion(models.ExclusionNotSenior) {
+		t.Errorf("hasExclusion() does not match recorded reasons: %v", trace.excluded)
+	}
+}
+
+func TestSelectionTraceSeedReproducible(t *testing.T) {
+	candidates := []models.User{{UserID: "u1"}, {UserID: "u2"}, {UserID: "u3"}, {UserID: "u4"}}
+
+	trace := newSelectionTrace(models.StrategyRandom)
+	first := selectRandomReviewers(trace.rng, candidates, 2)
+
+	// тот же seed дает тот же выбор
+	replay := newSelectionTrace(models.StrategyRandom)
+	replay.seed = trace.seed
+	replay.rng.Seed(trace.seed)
+	second := selectRandomReviewers(replay.rng, candidates, 2)
+
+	if !reflect.DeepEqual(userIDs(first), userIDs(second)) {
+		t.Errorf("selection with the same seed differs: %v vs %v", userIDs(first), userIDs(second))
+	}
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"pr-reviewer-service/internal/models"
+	"pr-reviewer-service/internal/repository"
+)
+
+// Ручное управление ревьюверами. Политики команды (стратегия, лимит открытых ревью, senior,
+// рабочее время) здесь не применяются: проверяется только то, без чего назначение некорректно,
+// и доступность пользователя - по тем же правилам, что и при автоматическом выборе
+
+// AddReviewer назначает на открытый PR указанного пользователя
+func (s *Service) AddReviewer(ctx context.Context, req models.ReviewerRequest) (*models.PullRequest, error) {
+	if req.PullRequestID == "" || req.UserID == "" {
+		return nil, fmt.Errorf("%s: pull_request_id and user_id are required", models.ErrCodeBadRequest)
+	}
+
+	pr, err := s.getOpenPR(ctx, req.PullRequestID)
+	if err != nil {
+		return nil, err
+	}
+	if len(pr.AssignedReviewers) >= models.MaxReviewersPerPR {
+		return nil, fmt.Errorf("%s: PR already has %d reviewers", models.ErrCodeBadRequest, models.MaxReviewersPerPR)
+	}
+
+	reviewer, err := s.checkManualReviewer(ctx, pr, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.addManualReviewer(ctx, pr, reviewer); err != nil {
+			return err
+		}
+		return s.saveManualExplanation(ctx, pr.PullRequestID, reviewer.UserID, "")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Reviewer %s manually added to PR %s", reviewer.UserID, pr.PullRequestID)
+
+	return s.getAssignedPR(ctx, pr.PullRequestID)
+}
+
+// RemoveReviewer снимает ревьювера с открытого PR без замены
+func (s *Service) RemoveReviewer(ctx context.Context, req models.ReviewerRequest) (*models.PullRequest, error) {
+	if req.PullRequestID == "" || req.UserID == "" {
+		return nil, fmt.Errorf("%s: pull_request_id and user_id are required", models.ErrCodeBadRequest)
+	}
+
+	pr, err := s.getOpenPR(ctx, req.PullRequestID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.RemoveReviewer(ctx, pr.PullRequestID, req.UserID); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return fmt.Errorf("%s: reviewer is not assigned to this PR", models.ErrCodeNotAssigned)
+			}
+			return err
+		}
+		return s.saveManualExplanation(ctx, pr.PullRequestID, "", req.UserID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Reviewer %s manually removed from PR %s", req.UserID, pr.PullRequestID)
+
+	return s.getAssignedPR(ctx, pr.PullRequestID)
+}
+
+// reassignToUser заменяет ревьювера на явно указанного пользователя
+func (s *Service) reassignToUser(
+	ctx context.Context, pr *models.PullRequest, req models.ReassignRequest,
+) (*models.PullRequest, string, error) {
+	if req.NewUserID == req.OldUserID {
+		return nil, "", fmt.Errorf("%s: new_user_id must differ from old_user_id", models.ErrCodeBadRequest)
+	}
+
+	reviewer, err := s.checkManualReviewer(ctx, pr, req.NewUserID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	err = s.repo.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.RemoveReviewer(ctx, pr.PullRequestID, req.OldUserID); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return fmt.Errorf("%s: reviewer is not assigned to this PR", models.ErrCodeNotAssigned)
+			}
+			return err
+		}
+		if err := s.addManualReviewer(ctx, pr, reviewer); err != nil {
+			return err
+		}
+		return s.saveManualExplanation(ctx, pr.PullRequestID, reviewer.UserID, req.OldUserID)
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	s.logger.Info("Reviewer %s manually replaced with %s on PR %s", req.OldUserID, reviewer.UserID, pr.PullRequestID)
+
+	updatedPR, err := s.getAssignedPR(ctx, pr.PullRequestID)
+	if err != nil {
+		return nil, "", err
+	}
+	return updatedPR, reviewer.UserID, nil
+}
+
+// getOpenPR возвращает PR, состав ревьюверов которого еще можно менять
+func (s *Service) getOpenPR(ctx context.Context, prID string) (*models.PullRequest, error) {
+	pr, err := s.repo.GetPR(ctx, prID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("%s: PR not found", models.ErrCodeNotFound)
+		}
+		return nil, err
+	}
+	if err := prStatusError(pr, "change reviewers on"); err != nil {
+		return nil, err
+	}
+	return pr, nil
+}
+
+// checkManualReviewer проверяет, что пользователя можно вручную назначить ревьювером PR
+func (s *Service) checkManualReviewer(ctx context.Context, pr *models.PullRequest, userID string) (*models.User, error) {
+	user, err := s.repo.GetUser(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("%s: user %s not found", models.ErrCodeNotFound, userID)
+		}
+		return nil, err
+	}
+
+	if user.UserID == pr.AuthorID {
+		return nil, fmt.Errorf("%s: author cannot review own PR", models.ErrCodeInvalidReviewer)
+	}
+	if !user.IsActive {
+		return nil, fmt.Errorf("%s: user %s is not active", models.ErrCodeInvalidReviewer, userID)
+	}
+	periods, err := s.repo.GetOutOfOffice(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if period := currentOutOfOffice(periods, time.Now()); period != nil {
+		return nil, fmt.Errorf("%s: user %s is out of office until %s",
+			models.ErrCodeInvalidReviewer, userID, period.EndsAt.Format(time.RFC3339))
+	}
+	for _, id := range pr.AssignedReviewers {
+		if id == userID {
+			return nil, fmt.Errorf("%s: user %s is already a reviewer of this PR", models.ErrCodeAlreadyAssigned, userID)
+		}
+	}
+
+	return user, nil
+}
+
+// currentOutOfOffice возвращает период отсутствия, который идет в момент now, или nil
+func currentOutOfOffice(periods []models.OutOfOffice, now time.Time) *models.OutOfOffice {
+	for i := range periods {
+		if !periods[i].StartsAt.After(now) && periods[i].EndsAt.After(now) {
+			return &periods[i]
+		}
+	}
+	return nil
+}
+
+// addManualReviewer назначает ревьювера. fallback_team не заполняется даже для пользователя
+// из чужой команды: эта отметка означает выбор из команды-партнера, а ручное назначение видно в объяснениях
+func (s *Service) addManualReviewer(ctx context.Context, pr *models.PullRequest, reviewer *models.User) error {
+	info := models.ReviewerInfo{UserID: reviewer.UserID}
+	if err := s.repo.AddReviewer(ctx, pr.PullRequestID, info); err != nil {
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			return fmt.Errorf("%s: user %s is already a reviewer of this PR", models.ErrCodeAlreadyAssigned, reviewer.UserID)
+		}
+		return err
+	}
+	return nil
+}
+
+// saveManualExplanation записывает ручное изменение: added - назначенный пользователь, removed - снятый
+func (s *Service) saveManualExplanation(ctx context.Context, prID, added, removed string) error {
+	explanation := &models.AssignmentExplanation{
+		PullRequestID: prID,
+		Kind:          models.AssignmentManual,
+		Strategy:      models.AssignmentManual,
+		CandidatePool: []string{},
+		Excluded:      []models.ExcludedCandidate{},
+		Selected:      []string{},
+	}
+	if added != "" {
+		explanation.CandidatePool = append(explanation.CandidatePool, added)
+		explanation.Selected = append(explanation.Selected, added)
+	}
+	if removed != "" {
+		explanation.CandidatePool = append(explanation.CandidatePool, removed)
+		explanation.Excluded = append(explanation.Excluded, models.ExcludedCandidate{UserID: removed, Reason: models.ExclusionRemoved})
+	}
+
+	return s.repo.AddAssignmentExplanation(ctx, explanation)
+}
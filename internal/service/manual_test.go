@@ -0,0 +1,41 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"pr-reviewer-service/internal/models"
+)
+
+func TestCurrentOutOfOffice(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	vacation := models.OutOfOffice{ID: 1, UserID: "u1", StartsAt: now.Add(-24 * time.Hour), EndsAt: now.Add(24 * time.Hour)}
+	planned := models.OutOfOffice{ID: 2, UserID: "u1", StartsAt: now.Add(48 * time.Hour), EndsAt: now.Add(72 * time.Hour)}
+	startsNow := models.OutOfOffice{ID: 3, UserID: "u1", StartsAt: now, EndsAt: now.Add(time.Hour)}
+	endsNow := models.OutOfOffice{ID: 4, UserID: "u1", StartsAt: now.Add(-time.Hour), EndsAt: now}
+
+	tests := []struct {
+		name    string
+		periods []models.OutOfOffice
+		wantID  int64
+	}{
+		{name: "нет периодов", periods: nil},
+		{name: "только будущий период", periods: []models.OutOfOffice{planned}},
+		{name: "идущий период", periods: []models.OutOfOffice{vacation, planned}, wantID: 1},
+		{name: "период начинается сейчас", periods: []models.OutOfOffice{startsNow}, wantID: 3},
+		{name: "период закончился сейчас", periods: []models.OutOfOffice{endsNow}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := currentOutOfOffice(tt.periods, now)
+			var gotID int64
+			if got != nil {
+				gotID = got.ID
+			}
+			if gotID != tt.wantID {
+				t.Errorf("currentOutOfOffice() = period %d, want %d", gotID, tt.wantID)
+			}
+		})
+	}
+}
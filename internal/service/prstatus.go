@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"pr-reviewer-service/internal/models"
+	"pr-reviewer-service/internal/repository"
+)
+
+// prStatusError возвращает ошибку, если действие недоступно PR в его текущем статусе.
+// Действия с ревьюверами и merge доступны только открытому PR
+func prStatusError(pr *models.PullRequest, action string) error {
+	status := strings.ToLower(pr.Status)
+	switch pr.Status {
+	case models.StatusMerged:
+		return fmt.Errorf("%s: cannot %s %s PR", models.ErrCodePRMerged, action, status)
+	case models.StatusClosed:
+		return fmt.Errorf("%s: cannot %s %s PR, reopen it first", models.ErrCodePRClosed, action, status)
+	case models.StatusDraft:
+		return fmt.Errorf("%s: cannot %s %s PR, mark it ready for review first", models.ErrCodePRDraft, action, status)
+	}
+	return nil
+}
+
+func (s *Service) getPRForStatusChange(ctx context.Context, prID string) (*models.PullRequest, error) {
+	if prID == "" {
+		return nil, fmt.Errorf("%s: pull_request_id is required", models.ErrCodeBadRequest)
+	}
+	pr, err := s.repo.GetPR(ctx, prID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("%s: PR not found", models.ErrCodeNotFound)
+		}
+		return nil, err
+	}
+	return pr, nil
+}
+
+// afterStatusRace обрабатывает PR, статус которого параллельный запрос изменил между чтением
+// и обновлением: уже открытый PR возвращается как есть, для остальных статусов - ошибка действия
+func (s *Service) afterStatusRace(ctx context.Context, prID, action string) (*models.PullRequest, error) {
+	pr, err := s.getPRForStatusChange(ctx, prID)
+	if err != nil {
+		return nil, err
+	}
+	if pr.Status == models.StatusOpen {
+		return s.getAssignedPR(ctx, prID)
+	}
+	return nil, prStatusError(pr, action)
+}
+
+// createDraftPR создает черновик: ревьюверы не выбираются, теги и файлы сохраняются для перевода в ready
+func (s *Service) createDraftPR(ctx context.Context, req models.CreatePRRequest) (*models.PullRequest, error) {
+	pr := &models.PullRequest{
+		PullRequestID:   req.PullRequestID,
+		Repository:      req.Repository,
+		Number:          req.Number,
+		PullRequestName: req.PullRequestName,
+		AuthorID:        req.AuthorID,
+		Status:          models.StatusDraft,
+		RequiredTags:    normalizeTags(req.RequiredTags),
+		ChangedFiles:    req.ChangedFiles,
+		PRMetadata:      req.PRMetadata,
+	}
+	if err := s.repo.CreatePR(ctx, pr, nil); err != nil {
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			return nil, fmt.Errorf("%s: %w", models.ErrCodePRExists, err)
+		}
+		return nil, err
+	}
+
+	s.logger.Info("Created draft PR %s by %s", req.PullRequestID, req.AuthorID)
+	return s.repo.GetPR(ctx, req.PullRequestID)
+}
+
+// MarkPRReady переводит черновик в OPEN и назначает ревьюверов так же, как при создании PR
+func (s *Service) MarkPRReady(ctx context.Context, req models.PRStatusRequest) (*models.PullRequest, error) {
+	pr, err := s.getPRForStatusChange(ctx, req.PullRequestID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch pr.Status {
+	case models.StatusOpen:
+		return s.getAssignedPR(ctx, pr.PullRequestID)
+	case models.StatusDraft:
+		if err := s.openForReview(ctx, pr); err != nil {
+			if errors.Is(err, repository.ErrStatusChanged) {
+				return s.afterStatusRace(ctx, pr.PullRequestID, "mark ready")
+			}
+			return nil, err
+		}
+		return s.getAssignedPR(ctx, pr.PullRequestID)
+	default:
+		return nil, prStatusError(pr, "mark ready")
+	}
+}
+
+// ClosePR закрывает открытый PR или черновик без merge
+func (s *Service) ClosePR(ctx context.Context, req models.PRStatusRequest) (*models.PullRequest, error) {
+	pr, err := s.getPRForStatusChange(ctx, req.PullRequestID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch pr.Status {
+	case models.StatusClosed:
+		return pr, nil
+	case models.StatusMerged:
+		return nil, prStatusError(pr, "close")
+	}
+
+	if err := s.repo.ClosePR(ctx, pr.PullRequestID); err != nil {
+		if errors.Is(err, repository.ErrStatusChanged) {
+			// статус изменил параллельный запрос: закрытый PR - как повторный вызов, слитый - PR_MERGED,
+			// снова открытый закрывается повторной попыткой
+			return s.ClosePR(ctx, req)
+		}
+		return nil, err
+	}
+	s.logger.Info("Closed PR %s", pr.PullRequestID)
+
+	return s.getAssignedPR(ctx, pr.PullRequestID)
+}
+
+// ReopenPR снова открывает закрытый PR. Ревьюверы сохраняются, а если их нет
+// (например, закрыт был черновик), назначаются заново
+func (s *Service) ReopenPR(ctx context.Context, req models.PRStatusRequest) (*models.PullRequest, error) {
+	pr, err := s.getPRForStatusChange(ctx, req.PullRequestID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch pr.Status {
+	case models.StatusOpen:
+		return s.getAssignedPR(ctx, pr.PullRequestID)
+	case models.StatusMerged, models.StatusDraft:
+		return nil, prStatusError(pr, "reopen")
+	}
+
+	if len(pr.AssignedReviewers) == 0 {
+		err = s.openForReview(ctx, pr)
+	} else {
+		err = s.repo.ReopenPR(ctx, pr.PullRequestID)
+	}
+	if err != nil {
+		if errors.Is(err, repository.ErrStatusChanged) {
+			return s.afterStatusRace(ctx, pr.PullRequestID, "reopen")
+		}
+		return nil, err
+	}
+	s.logger.Info("Reopened PR %s", pr.PullRequestID)
+
+	return s.getAssignedPR(ctx, pr.PullRequestID)
+}
+
+// openForReview выбирает ревьюверов по сохраненным тегам и файлам PR и переводит его в OPEN
+func (s *Service) openForReview(ctx context.Context, pr *models.PullRequest) error {
+	author, err := s.repo.GetUser(ctx, pr.AuthorID)
+	if err != nil {
+		return err
+	}
+	repo, err := s.prRepository(ctx, pr.Repository)
+	if err != nil {
+		return err
+	}
+	settings, err := s.prSettings(ctx, pr.PullRequestID, author, repo, pr.PRMetadata)
+	if err != nil {
+		return err
+	}
+
+	req := models.CreatePRRequest{
+		PullRequestID:   pr.PullRequestID,
+		PullRequestName: pr.PullRequestName,
+		AuthorID:        pr.AuthorID,
+		RequiredTags:    pr.RequiredTags,
+		ChangedFiles:    pr.ChangedFiles,
+		PRMetadata:      pr.PRMetadata,
+	}
+
+	return s.repo.WithTx(ctx, func(ctx context.Context) error {
+		assigned, err := s.selectInitialReviewers(ctx, newSelectionTrace(settings.AssignmentStrategy), author, settings, req)
+		if err != nil {
+			return err
+		}
+		reviewerIDs := userIDs(assigned.reviewers)
+
+		s.logger.Info("Assigned %d reviewers to PR %s opened for review using %s strategy: %v",
+			len(reviewerIDs), pr.PullRequestID, settings.AssignmentStrategy, reviewerIDs)
+
+		err = s.repo.OpenPRForReview(ctx, pr.PullRequestID, pr.Status, assigned.ownership, assigned.reviewerInfos())
+		if err != nil {
+			return err
+		}
+
+		_, err = s.saveExplanation(ctx, assigned.trace, pr.PullRequestID, models.AssignmentInitial, reviewerIDs)
+		return err
+	})
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"testing"
+
+	"pr-reviewer-service/internal/models"
+)
+
+func TestPRStatusError(t *testing.T) {
+	tests := []struct {
+		name   string
+		status string
+		code   string
+	}{
+		{"открытый PR", models.StatusOpen, ""},
+		{"merged PR", models.StatusMerged, models.ErrCodePRMerged},
+		{"закрытый PR", models.StatusClosed, models.ErrCodePRClosed},
+		{"черновик", models.StatusDraft, models.ErrCodePRDraft},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := prStatusError(&models.PullRequest{Status: tt.status}, "merge")
+			if tt.code == "" {
+				if err != nil {
+					t.Errorf("prStatusError() = %v, want nil", err)
+				}
+				return
+			}
+			if !hasErrorCode(err, tt.code) {
+				t.Errorf("prStatusError() = %v, want code %s", err, tt.code)
+			}
+		})
+	}
+}
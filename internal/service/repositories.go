@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"pr-reviewer-service/internal/models"
+	"pr-reviewer-service/internal/repository"
+)
+
+func (s *Service) CreateRepository(ctx context.Context, req models.CreateRepositoryRequest) (*models.Repository, error) {
+	repo, err := s.validateRepository(req)
+	if err != nil {
+		return nil, err
+	}
+	if len(repo.Teams) == 0 {
+		return nil, fmt.Errorf("%s: at least one owner team is required", models.ErrCodeBadRequest)
+	}
+
+	if err := s.repo.CreateRepository(ctx, repo); err != nil {
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			return nil, fmt.Errorf("%s: repository %s already exists", models.ErrCodeRepoExists, repo.RepositoryName)
+		}
+		return nil, err
+	}
+	s.logger.Info("Created repository %s owned by %v", repo.RepositoryName, repo.Teams)
+
+	return s.repo.GetRepository(ctx, repo.RepositoryName)
+}
+
+// UpdateRepository заменяет настройки репозитория, а если переданы команды - и владельцев
+func (s *Service) UpdateRepository(ctx context.Context, req models.CreateRepositoryRequest) (*models.Repository, error) {
+	repo, err := s.validateRepository(req)
+	if err != nil {
+		return nil, err
+	}
+	if repo.Teams != nil && len(repo.Teams) == 0 {
+		return nil, fmt.Errorf("%s: at least one owner team is required", models.ErrCodeBadRequest)
+	}
+
+	if err := s.repo.UpdateRepository(ctx, repo); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("%s: repository %s not found", models.ErrCodeNotFound, repo.RepositoryName)
+		}
+		return nil, err
+	}
+
+	return s.repo.GetRepository(ctx, repo.RepositoryName)
+}
+
+func (s *Service) GetRepository(ctx context.Context, repositoryName string) (*models.Repository, error) {
+	repo, err := s.repo.GetRepository(ctx, repositoryName)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("%s: repository %s not found", models.ErrCodeNotFound, repositoryName)
+		}
+		return nil, err
+	}
+	return repo, nil
+}
+
+// validateRepository проверяет имя и настройки репозитория; nil в Teams сохраняется (не менять владельцев)
+func (s *Service) validateRepository(req models.CreateRepositoryRequest) (models.Repository, error) {
+	repo := models.Repository{
+		RepositoryName:     strings.TrimSpace(req.RepositoryName),
+		RepositorySettings: req.RepositorySettings,
+	}
+	if repo.RepositoryName == "" {
+		return repo, fmt.Errorf("%s: repository_name is required", models.ErrCodeBadRequest)
+	}
+	// "#" разделяет репозиторий и номер в идентификаторе PR
+	if strings.Contains(repo.RepositoryName, "#") {
+		return repo, fmt.Errorf("%s: repository_name must not contain '#'", models.ErrCodeBadRequest)
+	}
+	if req.Teams != nil {
+		repo.Teams = uniqueIDs(req.Teams)
+	}
+
+	settings := repo.RepositorySettings
+	if settings.ReviewersPerPR != nil && (*settings.ReviewersPerPR < 1 || *settings.ReviewersPerPR > models.MaxReviewersPerPR) {
+		return repo, fmt.Errorf("%s: reviewers_per_pr must be between 1 and %d", models.ErrCodeBadRequest, models.MaxReviewersPerPR)
+	}
+	if settings.RequiredApprovals != nil && (*settings.RequiredApprovals < 0 || *settings.RequiredApprovals > models.MaxReviewersPerPR) {
+		return repo, fmt.Errorf("%s: required_approvals must be between 0 and %d",
+			models.ErrCodeBadRequest, models.MaxReviewersPerPR)
+	}
+	if settings.AssignmentStrategy != "" {
+		if _, ok := s.strategies[settings.AssignmentStrategy]; !ok {
+			return repo, fmt.Errorf("%s: unknown assignment_strategy %q", models.ErrCodeBadRequest, settings.AssignmentStrategy)
+		}
+	}
+
+	return repo, nil
+}
+
+// prRepository возвращает репозиторий PR, nil - PR без репозитория
+func (s *Service) prRepository(ctx context.Context, repositoryName string) (*models.Repository, error) {
+	if repositoryName == "" {
+		return nil, nil
+	}
+	return s.GetRepository(ctx, repositoryName)
+}
+
+// checkRepositoryOwner проверяет, что команда автора - одна из команд-владельцев репозитория PR
+func checkRepositoryOwner(author *models.User, repo *models.Repository) error {
+	if repo == nil || slices.Contains(repo.Teams, author.TeamName) {
+		return nil
+	}
+	return fmt.Errorf("%s: team %s does not own repository %s",
+		models.ErrCodeBadRequest, author.TeamName, repo.RepositoryName)
+}
+
+// applyRepositorySettings заменяет настройки команды заданными настройками репозитория
+func applyRepositorySettings(settings models.TeamSettings, repo *models.Repository) models.TeamSettings {
+	if repo == nil {
+		return settings
+	}
+	if repo.ReviewersPerPR != nil {
+		settings.ReviewersPerPR = *repo.ReviewersPerPR
+	}
+	if repo.AssignmentStrategy != "" {
+		settings.AssignmentStrategy = repo.AssignmentStrategy
+	}
+	if repo.RequireSenior != nil {
+		settings.RequireSenior = *repo.RequireSenior
+	}
+	if repo.RequiredApprovals != nil {
+		settings.RequiredApprovals = *repo.RequiredApprovals
+	}
+	return settings
+}
+
+// normalizePRKey задает pull_request_id PR репозитория: "repository#number"
+func normalizePRKey(req *models.CreatePRRequest) error {
+	req.Repository = strings.TrimSpace(req.Repository)
+	if req.Repository == "" {
+		if req.Number != 0 {
+			return fmt.Errorf("%s: number requires repository", models.ErrCodeBadRequest)
+		}
+		if strings.TrimSpace(req.PullRequestID) == "" {
+			return fmt.Errorf("%s: pull_request_id or repository and number are required", models.ErrCodeBadRequest)
+		}
+		return nil
+	}
+
+	if req.Number <= 0 {
+		return fmt.Errorf("%s: number must be positive for PR of repository %s", models.ErrCodeBadRequest, req.Repository)
+	}
+	key := prKey(req.Repository, req.Number)
+	if req.PullRequestID != "" && req.PullRequestID != key {
+		return fmt.Errorf("%s: pull_request_id of PR %d in %s must be %q", models.ErrCodeBadRequest, req.Number, req.Repository, key)
+	}
+	req.PullRequestID = key
+	return nil
+}
+
+// normalizePreviewKey - то же для пробного назначения: PR еще не создан, поэтому идентификатор
+// не обязателен и выводится, только если передан репозиторий с номером
+func normalizePreviewKey(req *models.CreatePRRequest) error {
+	req.Repository = strings.TrimSpace(req.Repository)
+	if req.Repository == "" && req.Number != 0 {
+		return fmt.Errorf("%s: number requires repository", models.ErrCodeBadRequest)
+	}
+	if req.Repository == "" || req.Number == 0 {
+		return nil
+	}
+	return normalizePRKey(req)
+}
+
+func prKey(repositoryName string, number int) string {
+	return fmt.Sprintf("%s#%d", repositoryName, number)
+}
+
+// groupByRepository группирует PR по репозиториям в порядке имен, PR без репозитория - последней группой.
+// Внутри группы порядок PR сохраняется
+func groupByRepository(prs []models.PullRequestShort) []models.RepositoryReviews {
+	groups := []models.RepositoryReviews{}
+	index := map[string]int{}
+	for _, pr := range prs {
+		i, ok := index[pr.Repository]
+		if !ok {
+			i = len(groups)
+			index[pr.Repository] = i
+			groups = append(groups, models.RepositoryReviews{Repository: pr.Repository})
+		}
+		groups[i].PullRequests = append(groups[i].PullRequests, pr)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		a, b := groups[i].Repository, groups[j].Repository
+		if a == "" || b == "" {
+			return b == "" && a != ""
+		}
+		return a < b
+	})
+	return groups
+}
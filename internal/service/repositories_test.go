@@ -0,0 +1,198 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"pr-reviewer-service/internal/models"
+)
+
+func TestNormalizePRKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     models.CreatePRRequest
+		wantID  string
+		wantErr bool
+	}{
+		{
+			name:   "PR без репозитория сохраняет идентификатор",
+			req:    models.CreatePRRequest{PullRequestID: "pr-1001"},
+			wantID: "pr-1001",
+		},
+		{
+			name:   "идентификатор из репозитория и номера",
+			req:    models.CreatePRRequest{Repository: " billing ", Number: 123},
+			wantID: "billing#123",
+		},
+		{
+			name:   "совпадающий pull_request_id допустим",
+			req:    models.CreatePRRequest{PullRequestID: "billing#123", Repository: "billing", Number: 123},
+			wantID: "billing#123",
+		},
+		{
+			name:    "pull_request_id не совпадает с номером",
+			req:     models.CreatePRRequest{PullRequestID: "123", Repository: "billing", Number: 123},
+			wantErr: true,
+		},
+		{
+			name:    "репозиторий без номера",
+			req:     models.CreatePRRequest{Repository: "billing"},
+			wantErr: true,
+		},
+		{
+			name:    "нет ни pull_request_id, ни репозитория",
+			req:     models.CreatePRRequest{PullRequestName: "Add search"},
+			wantErr: true,
+		},
+		{
+			name:    "номер без репозитория",
+			req:     models.CreatePRRequest{PullRequestID: "pr-1001", Number: 5},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := normalizePRKey(&tt.req)
+			if tt.wantErr {
+				if !hasErrorCode(err, models.ErrCodeBadRequest) {
+					t.Errorf("expected %s error, got %v", models.ErrCodeBadRequest, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.req.PullRequestID != tt.wantID {
+				t.Errorf("pull_request_id = %q, want %q", tt.req.PullRequestID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestNormalizePreviewKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     models.CreatePRRequest
+		wantID  string
+		wantErr bool
+	}{
+		{
+			name:   "без pull_request_id и репозитория",
+			req:    models.CreatePRRequest{PullRequestName: "Add search", AuthorID: "u1"},
+			wantID: "",
+		},
+		{
+			name:   "репозиторий без номера",
+			req:    models.CreatePRRequest{Repository: " billing ", AuthorID: "u1"},
+			wantID: "",
+		},
+		{
+			name:   "идентификатор из репозитория и номера",
+			req:    models.CreatePRRequest{Repository: "billing", Number: 123},
+			wantID: "billing#123",
+		},
+		{
+			name:    "номер без репозитория",
+			req:     models.CreatePRRequest{Number: 5},
+			wantErr: true,
+		},
+		{
+			name:    "pull_request_id не совпадает с номером",
+			req:     models.CreatePRRequest{PullRequestID: "123", Repository: "billing", Number: 123},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := normalizePreviewKey(&tt.req)
+			if tt.wantErr {
+				if !hasErrorCode(err, models.ErrCodeBadRequest) {
+					t.Errorf("expected %s error, got %v", models.ErrCodeBadRequest, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.req.PullRequestID != tt.wantID {
+				t.Errorf("pull_request_id = %q, want %q", tt.req.PullRequestID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestCheckRepositoryOwner(t *testing.T) {
+	repo := &models.Repository{RepositoryName: "billing", Teams: []string{"backend", "payments"}}
+
+	tests := []struct {
+		name    string
+		author  models.User
+		repo    *models.Repository
+		wantErr bool
+	}{
+		{name: "PR без репозитория", author: models.User{UserID: "u1", TeamName: "frontend"}, repo: nil},
+		{name: "команда автора владеет репозиторием", author: models.User{UserID: "u1", TeamName: "payments"}, repo: repo},
+		{name: "команда автора не владеет репозиторием", author: models.User{UserID: "u1", TeamName: "frontend"}, repo: repo, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkRepositoryOwner(&tt.author, tt.repo)
+			if tt.wantErr {
+				if !hasErrorCode(err, models.ErrCodeBadRequest) {
+					t.Errorf("expected %s error, got %v", models.ErrCodeBadRequest, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestGroupByRepository(t *testing.T) {
+	prs := []models.PullRequestShort{
+		{PullRequestID: "web#7", Repository: "web"},
+		{PullRequestID: "pr-1001"},
+		{PullRequestID: "api#12", Repository: "api"},
+		{PullRequestID: "web#3", Repository: "web"},
+	}
+
+	got := groupByRepository(prs)
+	want := []models.RepositoryReviews{
+		{Repository: "api", PullRequests: []models.PullRequestShort{prs[2]}},
+		{Repository: "web", PullRequests: []models.PullRequestShort{prs[0], prs[3]}},
+		{Repository: "", PullRequests: []models.PullRequestShort{prs[1]}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("groupByRepository() = %+v, want %+v", got, want)
+	}
+
+	if got := groupByRepository(nil); len(got) != 0 {
+		t.Errorf("expected no groups, got %+v", got)
+	}
+}
+
+func TestApplyRepositorySettings(t *testing.T) {
+	intPtr := func(v int) *int { return &v }
+	team := models.TeamSettings{
+		AssignmentStrategy: models.StrategyRoundRobin,
+		ReviewersPerPR:     2,
+		RequiredApprovals:  1,
+	}
+
+	got := applyRepositorySettings(team, &models.Repository{
+		RepositorySettings: models.RepositorySettings{ReviewersPerPR: intPtr(3), RequiredApprovals: intPtr(2)},
+	})
+	want := models.TeamSettings{AssignmentStrategy: models.StrategyRoundRobin, ReviewersPerPR: 3, RequiredApprovals: 2}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("applyRepositorySettings() = %+v, want %+v", got, want)
+	}
+
+	if got := applyRepositorySettings(team, nil); !reflect.DeepEqual(got, team) {
+		t.Errorf("without repository settings must not change, got %+v", got)
+	}
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"pr-reviewer-service/internal/models"
+	"pr-reviewer-service/internal/repository"
+)
+
+var validVerdicts = map[string]bool{
+	models.VerdictApproved:         true,
+	models.VerdictChangesRequested: true,
+	models.VerdictCommented:        true,
+}
+
+// SubmitReview сохраняет итог ревью назначенного ревьювера открытого PR
+func (s *Service) SubmitReview(ctx context.Context, req models.SubmitReviewRequest) (*models.PullRequest, error) {
+	if req.PullRequestID == "" || req.UserID == "" {
+		return nil, fmt.Errorf("%s: pull_request_id and user_id are required", models.ErrCodeBadRequest)
+	}
+	if !validVerdicts[req.Verdict] {
+		return nil, fmt.Errorf("%s: verdict must be one of %s, %s, %s", models.ErrCodeBadRequest,
+			models.VerdictApproved, models.VerdictChangesRequested, models.VerdictCommented)
+	}
+
+	pr, err := s.GetPR(ctx, req.PullRequestID)
+	if err != nil {
+		return nil, err
+	}
+	if err := prStatusError(pr, "review"); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.SetReviewVerdict(ctx, req.PullRequestID, req.UserID, req.Verdict); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("%s: reviewer is not assigned to this PR", models.ErrCodeNotAssigned)
+		}
+		return nil, err
+	}
+	s.logger.Info("Reviewer %s submitted %s on PR %s", req.UserID, req.Verdict, req.PullRequestID)
+
+	return s.getAssignedPR(ctx, req.PullRequestID)
+}
+
+// RerequestReview начинает новый раунд ревью открытого PR у тех же ревьюверов:
+// их итоги сбрасываются, а история прошлых раундов сохраняется
+func (s *Service) RerequestReview(ctx context.Context, req models.RerequestReviewRequest) (*models.PullRequest, error) {
+	if req.PullRequestID == "" {
+		return nil, fmt.Errorf("%s: pull_request_id is required", models.ErrCodeBadRequest)
+	}
+
+	pr, err := s.GetPR(ctx, req.PullRequestID)
+	if err != nil {
+		return nil, err
+	}
+	if err := prStatusError(pr, "re-request review on"); err != nil {
+		return nil, err
+	}
+	if len(pr.AssignedReviewers) == 0 {
+		return nil, fmt.Errorf("%s: PR has no reviewers to re-request review from", models.ErrCodeNotAssigned)
+	}
+
+	round, err := s.repo.StartReviewRound(ctx, req.PullRequestID)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("Started review round %d on PR %s for %v", round, req.PullRequestID, pr.AssignedReviewers)
+
+	return s.getAssignedPR(ctx, req.PullRequestID)
+}
+
+// GetReviewRounds возвращает раунды ревью PR с историей итогов
+func (s *Service) GetReviewRounds(ctx context.Context, prID string) ([]models.ReviewRound, error) {
+	if _, err := s.repo.GetPR(ctx, prID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("%s: PR not found", models.ErrCodeNotFound)
+		}
+		return nil, err
+	}
+	return s.repo.GetReviewRounds(ctx, prID)
+}
+
+// GetPR возвращает PR с ревьюверами и их итогами ревью
+func (s *Service) GetPR(ctx context.Context, prID string) (*models.PullRequest, error) {
+	pr, err := s.getAssignedPR(ctx, prID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("%s: PR not found", models.ErrCodeNotFound)
+		}
+		return nil, err
+	}
+	return pr, nil
+}
+
+// checkApprovals проверяет, что PR одобрен нужным числом ревьюверов по настройке
+// репозитория PR или команды автора
+func (s *Service) checkApprovals(ctx context.Context, pr *models.PullRequest) error {
+	author, err := s.repo.GetUser(ctx, pr.AuthorID)
+	if err != nil {
+		return err
+	}
+	teamSettings, err := s.repo.GetTeamSettings(ctx, author.TeamName)
+	if err != nil {
+		return err
+	}
+	repo, err := s.prRepository(ctx, pr.Repository)
+	if err != nil {
+		return err
+	}
+	settings := applyRepositorySettings(*teamSettings, repo)
+
+	if approvals := countApprovals(pr.Reviewers); approvals < settings.RequiredApprovals {
+		return fmt.Errorf("%s: PR has %d of %d required approvals",
+			models.ErrCodeNotApproved, approvals, settings.RequiredApprovals)
+	}
+	return nil
+}
+
+func countApprovals(reviewers []models.ReviewerInfo) int {
+	count := 0
+	for _, r := range reviewers {
+		if r.Verdict == models.VerdictApproved {
+			count++
+		}
+	}
+	return count
+}
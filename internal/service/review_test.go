@@ -0,0 +1,23 @@
+package service
+
+import (
+	"testing"
+
+	"pr-reviewer-service/internal/models"
+)
+
+func TestCountApprovals(t *testing.T) {
+	reviewers := []models.ReviewerInfo{
+		{UserID: "u1", Verdict: models.VerdictApproved},
+		{UserID: "u2", Verdict: models.VerdictChangesRequested},
+		{UserID: "u3", Verdict: models.VerdictCommented},
+		{UserID: "u4"},
+		{UserID: "u5", Verdict: models.VerdictApproved},
+	}
+	if got := countApprovals(reviewers); got != 2 {
+		t.Errorf("countApprovals() = %d, want 2", got)
+	}
+	if got := countApprovals(nil); got != 0 {
+		t.Errorf("countApprovals(nil) = %d, want 0", got)
+	}
+}
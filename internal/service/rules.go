@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"pr-reviewer-service/internal/models"
+)
+
+var validPriorities = map[string]bool{
+	models.PriorityLow:    true,
+	models.PriorityNormal: true,
+	models.PriorityUrgent: true,
+}
+
+// normalizePRMetadata проверяет размер и приоритет PR и заполняет значения по умолчанию
+func normalizePRMetadata(req *models.CreatePRRequest) error {
+	if req.LinesAdded < 0 || req.LinesRemoved < 0 || req.FilesChanged < 0 {
+		return fmt.Errorf("%s: lines_added, lines_removed and files_changed must not be negative", models.ErrCodeBadRequest)
+	}
+	if req.FilesChanged == 0 {
+		req.FilesChanged = len(req.ChangedFiles)
+	}
+
+	req.Priority = strings.ToLower(strings.TrimSpace(req.Priority))
+	if req.Priority == "" {
+		req.Priority = models.PriorityNormal
+	}
+	if !validPriorities[req.Priority] {
+		return fmt.Errorf("%s: priority must be one of low, normal, urgent", models.ErrCodeBadRequest)
+	}
+
+	req.Labels = normalizeTags(req.Labels)
+	return nil
+}
+
+// validateAssignmentRules нормализует правила назначения команды и проверяет их
+func (s *Service) validateAssignmentRules(rules []models.AssignmentRule) ([]models.AssignmentRule, error) {
+	if len(rules) > models.MaxAssignmentRules {
+		return nil, fmt.Errorf("%s: at most %d assignment_rules are allowed", models.ErrCodeBadRequest, models.MaxAssignmentRules)
+	}
+
+	result := make([]models.AssignmentRule, len(rules))
+	for i, rule := range rules {
+		rule.Priority = strings.ToLower(strings.TrimSpace(rule.Priority))
+		rule.Label = strings.ToLower(strings.TrimSpace(rule.Label))
+
+		invalid := func(msg string) error {
+			return fmt.Errorf("%s: assignment_rules[%d]: %s", models.ErrCodeBadRequest, i, msg)
+		}
+		if rule.MinLines == nil && rule.MinFiles == nil && rule.Priority == "" && rule.Label == "" {
+			return nil, invalid("at least one condition is required (min_lines, min_files, priority, label)")
+		}
+		if rule.ReviewersPerPR == nil && rule.AssignmentStrategy == "" && rule.RequireSenior == nil {
+			return nil, invalid("at least one action is required (reviewers_per_pr, assignment_strategy, require_senior)")
+		}
+		if (rule.MinLines != nil && *rule.MinLines < 0) || (rule.MinFiles != nil && *rule.MinFiles < 0) {
+			return nil, invalid("min_lines and min_files must not be negative")
+		}
+		if rule.Priority != "" && !validPriorities[rule.Priority] {
+			return nil, invalid(fmt.Sprintf("unknown priority %q", rule.Priority))
+		}
+		if rule.ReviewersPerPR != nil && (*rule.ReviewersPerPR < 1 || *rule.ReviewersPerPR > models.MaxReviewersPerPR) {
+			return nil, invalid(fmt.Sprintf("reviewers_per_pr must be between 1 and %d", models.MaxReviewersPerPR))
+		}
+		if rule.AssignmentStrategy != "" {
+			if _, ok := s.strategies[rule.AssignmentStrategy]; !ok {
+				return nil, invalid(fmt.Sprintf("unknown assignment_strategy %q", rule.AssignmentStrategy))
+			}
+		}
+		result[i] = rule
+	}
+
+	return result, nil
+}
+
+// applyAssignmentRules возвращает настройки команды для PR с учетом подходящих правил
+// и номера примененных правил
+func applyAssignmentRules(settings models.TeamSettings, meta models.PRMetadata) (models.TeamSettings, []int) {
+	var matched []int
+	for i, rule := range settings.AssignmentRules {
+		if !ruleMatches(rule, meta) {
+			continue
+		}
+		matched = append(matched, i)
+
+		if rule.ReviewersPerPR != nil {
+			settings.ReviewersPerPR = *rule.ReviewersPerPR
+		}
+		if rule.AssignmentStrategy != "" {
+			settings.AssignmentStrategy = rule.AssignmentStrategy
+		}
+		if rule.RequireSenior != nil {
+			settings.RequireSenior = *rule.RequireSenior
+		}
+	}
+	return settings, matched
+}
+
+func ruleMatches(rule models.AssignmentRule, meta models.PRMetadata) bool {
+	if rule.MinLines != nil && meta.LinesAdded+meta.LinesRemoved < *rule.MinLines {
+		return false
+	}
+	if rule.MinFiles != nil && meta.FilesChanged < *rule.MinFiles {
+		return false
+	}
+	if rule.Priority != "" && rule.Priority != meta.Priority {
+		return false
+	}
+	if rule.Label != "" && !toSet(meta.Labels)[rule.Label] {
+		return false
+	}
+	return true
+}
+
+// prSettings возвращает настройки команды автора для этого PR: с учетом настроек репозитория
+// (repo может быть nil) и затем правил назначения команды
+func (s *Service) prSettings(
+	ctx context.Context, prID string, author *models.User, repo *models.Repository, meta models.PRMetadata,
+) (*models.TeamSettings, error) {
+	settings, err := s.repo.GetTeamSettings(ctx, author.TeamName)
+	if err != nil {
+		return nil, err
+	}
+
+	effective, matched := applyAssignmentRules(applyRepositorySettings(*settings, repo), meta)
+	if len(matched) > 0 {
+		s.logger.Info("PR %s matched assignment rules %v of team %s: %d reviewers, %s strategy",
+			prID, matched, author.TeamName, effective.ReviewersPerPR, effective.AssignmentStrategy)
+	}
+	return &effective, nil
+}
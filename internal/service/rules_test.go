@@ -0,0 +1,127 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"pr-reviewer-service/internal/models"
+)
+
+func TestApplyAssignmentRules(t *testing.T) {
+	intPtr := func(v int) *int { return &v }
+	enabled := true
+	base := models.TeamSettings{
+		AssignmentStrategy: models.StrategyRandom,
+		ReviewersPerPR:     2,
+		AssignmentRules: []models.AssignmentRule{
+			{MinLines: intPtr(501), ReviewersPerPR: intPtr(3)},
+			{Priority: models.PriorityUrgent, AssignmentStrategy: models.StrategyLeastLoaded},
+			{Label: "security", RequireSenior: &enabled, ReviewersPerPR: intPtr(4)},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		meta         models.PRMetadata
+		wantReviews  int
+		wantStrategy string
+		wantSenior   bool
+		wantMatched  []int
+	}{
+		{
+			name:         "ни одно правило не подходит",
+			meta:         models.PRMetadata{LinesAdded: 300, LinesRemoved: 200, Priority: models.PriorityNormal},
+			wantReviews:  2,
+			wantStrategy: models.StrategyRandom,
+		},
+		{
+			name:         "больше 500 строк",
+			meta:         models.PRMetadata{LinesAdded: 400, LinesRemoved: 101, Priority: models.PriorityNormal},
+			wantReviews:  3,
+			wantStrategy: models.StrategyRandom,
+			wantMatched:  []int{0},
+		},
+		{
+			name:         "большой срочный PR - применяются оба правила",
+			meta:         models.PRMetadata{LinesAdded: 800, Priority: models.PriorityUrgent},
+			wantReviews:  3,
+			wantStrategy: models.StrategyLeastLoaded,
+			wantMatched:  []int{0, 1},
+		},
+		{
+			name:         "более позднее правило переопределяет раннее",
+			meta:         models.PRMetadata{LinesAdded: 800, Priority: models.PriorityNormal, Labels: []string{"api", "security"}},
+			wantReviews:  4,
+			wantStrategy: models.StrategyRandom,
+			wantSenior:   true,
+			wantMatched:  []int{0, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, matched := applyAssignmentRules(base, tt.meta)
+			if got.ReviewersPerPR != tt.wantReviews || got.AssignmentStrategy != tt.wantStrategy || got.RequireSenior != tt.wantSenior {
+				t.Errorf("applyAssignmentRules() = %d, %s, senior %v, want %d, %s, senior %v",
+					got.ReviewersPerPR, got.AssignmentStrategy, got.RequireSenior, tt.wantReviews, tt.wantStrategy, tt.wantSenior)
+			}
+			if !reflect.DeepEqual(matched, tt.wantMatched) {
+				t.Errorf("matched = %v, want %v", matched, tt.wantMatched)
+			}
+		})
+	}
+
+	if base.ReviewersPerPR != 2 {
+		t.Errorf("team settings must not change, got reviewers_per_pr %d", base.ReviewersPerPR)
+	}
+}
+
+func TestValidateAssignmentRules(t *testing.T) {
+	s := &Service{strategies: defaultStrategies(nil)}
+	intPtr := func(v int) *int { return &v }
+
+	got, err := s.validateAssignmentRules([]models.AssignmentRule{
+		{Priority: " Urgent ", Label: "Security", AssignmentStrategy: models.StrategyLeastLoaded},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got[0].Priority != models.PriorityUrgent || got[0].Label != "security" {
+		t.Errorf("expected normalized priority and label, got %+v", got[0])
+	}
+
+	invalid := map[string]models.AssignmentRule{
+		"без условий":           {ReviewersPerPR: intPtr(3)},
+		"без действий":          {MinLines: intPtr(500)},
+		"неизвестный приоритет": {Priority: "critical", ReviewersPerPR: intPtr(3)},
+		"неизвестная стратегия": {Priority: models.PriorityUrgent, AssignmentStrategy: "alphabetical"},
+		"ревьюверов больше 10":  {MinFiles: intPtr(10), ReviewersPerPR: intPtr(models.MaxReviewersPerPR + 1)},
+		"отрицательное условие": {MinLines: intPtr(-1), ReviewersPerPR: intPtr(3)},
+	}
+	for name, rule := range invalid {
+		t.Run(name, func(t *testing.T) {
+			if _, err := s.validateAssignmentRules([]models.AssignmentRule{rule}); !hasErrorCode(err, models.ErrCodeBadRequest) {
+				t.Errorf("expected %s error, got %v", models.ErrCodeBadRequest, err)
+			}
+		})
+	}
+}
+
+func TestNormalizePRMetadata(t *testing.T) {
+	req := models.CreatePRRequest{
+		ChangedFiles: []string{"a.go", "b.go"},
+		PRMetadata:   models.PRMetadata{Labels: []string{"Bug", " bug", "api"}},
+	}
+	if err := normalizePRMetadata(&req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := models.PRMetadata{FilesChanged: 2, Priority: models.PriorityNormal, Labels: []string{"api", "bug"}}
+	if !reflect.DeepEqual(req.PRMetadata, want) {
+		t.Errorf("normalizePRMetadata() = %+v, want %+v", req.PRMetadata, want)
+	}
+
+	req = models.CreatePRRequest{PRMetadata: models.PRMetadata{LinesAdded: -5}}
+	if err := normalizePRMetadata(&req); !hasErrorCode(err, models.ErrCodeBadRequest) {
+		t.Errorf("expected %s error for negative lines, got %v", models.ErrCodeBadRequest, err)
+	}
+}
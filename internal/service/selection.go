@@ -0,0 +1,442 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"pr-reviewer-service/internal/models"
+	"pr-reviewer-service/internal/repository"
+)
+
+// selectReviewers выбирает до maxCount ревьюверов стратегией команды за одно ее решение. Кандидаты
+// передаются группами по убыванию приоритета: следующая группа рассматривается, только если
+// в предыдущих не хватило кандидатов. Порядок критериев см. в orderCandidates.
+// Все рассмотренные кандидаты попадают в пул trace, кандидаты с достигнутым лимитом открытых ревью
+// пропускаются и отмечаются в trace как исключенные
+func (s *Service) selectReviewers(
+	ctx context.Context, trace *selectionTrace, teamName, strategyName string, groups [][]models.User,
+	recent map[string]bool, maxCount int,
+) ([]models.User, error) {
+	if maxCount <= 0 {
+		return []models.User{}, nil
+	}
+
+	var all []models.User
+	for _, group := range groups {
+		all = append(all, group...)
+	}
+	if len(all) == 0 {
+		return []models.User{}, nil
+	}
+	trace.consider(all)
+
+	groups = orderCandidates(groups, recent, time.Now())
+
+	strategy := s.teamStrategy(teamName, strategyName)
+	trace.strategy = strategy.Name()
+
+	// нагрузку подгружаем один раз для всех групп
+	load, err := s.repo.GetOpenReviewCounts(ctx, userIDs(all))
+	if err != nil {
+		return nil, err
+	}
+
+	for i, group := range groups {
+		groups[i] = filterUsers(group, func(u models.User) bool {
+			if atCapacity(u, load) {
+				trace.exclude(u.UserID, models.ExclusionAtCapacity)
+				return false
+			}
+			return true
+		})
+	}
+
+	return strategy.Select(ctx, SelectionRequest{
+		TeamName: teamName,
+		Groups:   groups,
+		Load:     load,
+		Count:    maxCount,
+		Rand:     trace.rng,
+		DryRun:   trace.dryRun,
+	})
+}
+
+// teamStrategy возвращает стратегию команды, для неизвестной - стратегию по умолчанию
+func (s *Service) teamStrategy(teamName, strategyName string) ReviewerStrategy {
+	strategy, ok := s.strategies[strategyName]
+	if !ok {
+		s.logger.Warn("Unknown assignment strategy %q for team %s, falling back to %s", strategyName, teamName, models.DefaultStrategy)
+		strategy = s.strategies[models.DefaultStrategy]
+	}
+	return strategy
+}
+
+// orderCandidates упорядочивает кандидатов по критериям, от более важного к менее важному:
+//  1. группы вызывающего в исходном порядке (владельцы кода, senior, теги PR);
+//  2. доступность: сейчас в рабочем времени, затем те, у кого оно начнется раньше всех, затем остальные;
+//  3. свежесть пары: недавние ревьюверы автора (pairing_lookback) после остальных.
+//
+// Свежесть - самый мягкий критерий: недавний ревьювер в рабочем времени важнее того,
+// кто давно не ревьюил автора, но сейчас не работает
+func orderCandidates(groups [][]models.User, recent map[string]bool, now time.Time) [][]models.User {
+	return preferFresh(preferAvailable(groups, now), recent)
+}
+
+// preferFresh внутри каждой группы ставит недавних ревьюверов автора после остальных
+func preferFresh(groups [][]models.User, recent map[string]bool) [][]models.User {
+	if len(recent) == 0 {
+		return groups
+	}
+	return preferBy(groups, func(u models.User) bool {
+		return !recent[u.UserID]
+	})
+}
+
+// atCapacity - у пользователя достигнут лимит открытых ревью
+func atCapacity(u models.User, load map[string]int) bool {
+	return u.MaxOpenReviews != nil && load[u.UserID] >= *u.MaxOpenReviews
+}
+
+// результат выбора ревьюверов для нового PR
+type initialAssignment struct {
+	reviewers    []models.User
+	requiredTags []string
+	ownership    *models.OwnershipMatch
+	// ревьюверы из команд-партнеров: user_id -> команда
+	fallbackTeams map[string]string
+	// ревьюверы последних PR автора, среди одинаково доступных кандидатов они рассматриваются последними
+	recentReviewers map[string]bool
+	trace           *selectionTrace
+}
+
+func (a *initialAssignment) reviewerInfos() []models.ReviewerInfo {
+	infos := make([]models.ReviewerInfo, len(a.reviewers))
+	for i, r := range a.reviewers {
+		infos[i] = models.ReviewerInfo{UserID: r.UserID, FallbackTeam: a.fallbackTeams[r.UserID]}
+	}
+	return infos
+}
+
+// selectInitialReviewers выбирает ревьюверов для нового PR: сначала одного владельца
+// измененных файлов по CODEOWNERS команды, затем (если этого требует политика команды)
+// одного senior, затем остальных из команды автора, отдавая приоритет участникам с нужными тегами,
+// затем тем, кто сейчас работает, и затем тем, кто не ревьюил последние PR автора.
+// Если в команде не хватает кандидатов, оставшиеся места заполняются из команд-партнеров
+func (s *Service) selectInitialReviewers(
+	ctx context.Context, trace *selectionTrace, author *models.User, settings *models.TeamSettings, req models.CreatePRRequest,
+) (*initialAssignment, error) {
+	result := &initialAssignment{
+		requiredTags: normalizeTags(req.RequiredTags),
+		trace:        trace,
+	}
+	result.trace.exclude(author.UserID, models.ExclusionAuthor)
+	result.trace.addTeam(author.TeamName)
+
+	// очереди всех команд, из которых может выбираться PR, блокируются сразу и в порядке имен,
+	// а не по мере выбора: иначе PR двух команд, указавших друг друга партнерами, ждали бы друг друга
+	if settings.AssignmentStrategy == models.StrategyRoundRobin && !trace.dryRun {
+		teams := append([]string{author.TeamName}, settings.FallbackTeams...)
+		if err := s.repo.LockTeamRotations(ctx, teams); err != nil {
+			return nil, err
+		}
+	}
+
+	if settings.PairingLookback > 0 {
+		recent, err := s.repo.GetRecentReviewers(ctx, author.UserID, settings.PairingLookback)
+		if err != nil {
+			return nil, err
+		}
+		result.recentReviewers = recent
+	}
+
+	owner, ownership, err := s.pickCodeOwner(ctx, author, settings, req.ChangedFiles, result)
+	if err != nil {
+		return nil, err
+	}
+
+	exclude := map[string]bool{author.UserID: true}
+	if owner != nil {
+		result.reviewers = append(result.reviewers, *owner)
+		result.ownership = ownership
+		exclude[owner.UserID] = true
+	}
+
+	// получаем активных участников команды кроме автора
+	candidates, err := s.repo.GetActiveTeamMembers(ctx, author.TeamName, author.UserID)
+	if err != nil {
+		return nil, err
+	}
+	result.trace.consider(candidates)
+
+	// гарантируем хотя бы одного senior, если он есть среди кандидатов
+	if settings.RequireSenior && !containsSenior(result.reviewers) && len(result.reviewers) < settings.ReviewersPerPR {
+		seniors := filterUsers(withoutUsers(candidates, exclude), isSenior)
+		groups := splitByTags(seniors, result.requiredTags)
+		senior, err := s.selectReviewers(ctx, result.trace, author.TeamName, settings.AssignmentStrategy, groups,
+			result.recentReviewers, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(senior) > 0 {
+			result.reviewers = append(result.reviewers, senior[0])
+			exclude[senior[0].UserID] = true
+		}
+	}
+
+	// в первую очередь рассматриваем тех, чьи теги покрывают теги PR
+	groups := splitByTags(withoutUsers(candidates, exclude), result.requiredTags)
+	rest, err := s.selectReviewers(ctx, result.trace, author.TeamName, settings.AssignmentStrategy, groups,
+		result.recentReviewers, settings.ReviewersPerPR-len(result.reviewers))
+	if err != nil {
+		return nil, err
+	}
+	result.reviewers = append(result.reviewers, rest...)
+	for _, r := range rest {
+		exclude[r.UserID] = true
+	}
+
+	if err := s.fillFromFallbackTeams(ctx, author, settings, result, exclude); err != nil {
+		return nil, err
+	}
+
+	// назначить некого только из-за лимитов - это ошибка, а не PR без ревьюверов
+	if len(result.reviewers) == 0 && result.trace.hasExclusion(models.ExclusionAtCapacity) {
+		return nil, fmt.Errorf("%s: all candidates reached max_open_reviews", models.ErrCodeNoCapacity)
+	}
+
+	return result, nil
+}
+
+// fillFromFallbackTeams добирает недостающих ревьюверов из команд-партнеров в порядке приоритета.
+// Если senior среди ревьюверов еще нет, а политика команды его требует, senior выбираются в первую очередь
+func (s *Service) fillFromFallbackTeams(
+	ctx context.Context, author *models.User, settings *models.TeamSettings, result *initialAssignment, exclude map[string]bool,
+) error {
+	for _, fallbackTeam := range settings.FallbackTeams {
+		missing := settings.ReviewersPerPR - len(result.reviewers)
+		if missing <= 0 {
+			return nil
+		}
+
+		candidates, err := s.repo.GetActiveTeamMembers(ctx, fallbackTeam, author.UserID)
+		if err != nil {
+			return err
+		}
+		result.trace.addTeam(fallbackTeam)
+		result.trace.consider(candidates)
+
+		groups := [][]models.User{withoutUsers(candidates, exclude)}
+		if settings.RequireSenior && !containsSenior(result.reviewers) {
+			groups = preferBy(groups, isSenior)
+		}
+		groups = preferTags(groups, result.requiredTags)
+
+		picked, err := s.selectReviewers(ctx, result.trace, fallbackTeam, settings.AssignmentStrategy, groups,
+			result.recentReviewers, missing)
+		if err != nil {
+			return err
+		}
+
+		for _, r := range picked {
+			if result.fallbackTeams == nil {
+				result.fallbackTeams = make(map[string]string)
+			}
+			result.fallbackTeams[r.UserID] = fallbackTeam
+			result.reviewers = append(result.reviewers, r)
+			exclude[r.UserID] = true
+		}
+	}
+
+	return nil
+}
+
+// pickCodeOwner выбирает одного ревьювера среди активных владельцев измененных файлов.
+// Возвращает nil, если файлы не переданы, у команды нет CODEOWNERS или подходящих владельцев нет
+func (s *Service) pickCodeOwner(
+	ctx context.Context, author *models.User, settings *models.TeamSettings, files []string, result *initialAssignment,
+) (*models.User, *models.OwnershipMatch, error) {
+	if len(files) == 0 {
+		return nil, nil, nil
+	}
+
+	content, err := s.repo.GetCodeowners(ctx, author.TeamName)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+
+	// содержимое проверяется при загрузке, поэтому ошибка здесь означает порчу данных
+	rules, err := parseCodeowners(content)
+	if err != nil {
+		s.logger.Warn("Invalid CODEOWNERS for team %s: %v", author.TeamName, err)
+		return nil, nil, nil
+	}
+
+	matches := matchOwnership(rules, files)
+	ownerIDs := []string{}
+	for _, m := range matches {
+		for _, id := range m.Owners {
+			if id != author.UserID {
+				ownerIDs = append(ownerIDs, id)
+			}
+		}
+	}
+	if len(ownerIDs) == 0 {
+		return nil, nil, nil
+	}
+
+	owners, err := s.repo.GetActiveUsers(ctx, ownerIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// при политике senior владелец-senior закрывает оба требования одним местом
+	groups := [][]models.User{owners}
+	if settings.RequireSenior {
+		groups = preferBy(groups, isSenior)
+	}
+	groups = preferTags(groups, result.requiredTags)
+
+	picked, err := s.selectReviewers(ctx, result.trace, author.TeamName, settings.AssignmentStrategy, groups,
+		result.recentReviewers, 1)
+	if err != nil || len(picked) == 0 {
+		return nil, nil, err
+	}
+	owner := picked[0]
+
+	// в ответе указываем первое правило, по которому выбранный ревьювер - владелец
+	for _, m := range matches {
+		for _, id := range m.Owners {
+			if id == owner.UserID {
+				m.Owner = owner.UserID
+				return &owner, &m, nil
+			}
+		}
+	}
+
+	return &owner, nil, nil
+}
+
+// withoutUsers возвращает кандидатов, не попавших в exclude
+func withoutUsers(candidates []models.User, exclude map[string]bool) []models.User {
+	filtered := []models.User{}
+	for _, c := range candidates {
+		if !exclude[c.UserID] {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered
+}
+
+func filterUsers(candidates []models.User, pred func(models.User) bool) []models.User {
+	filtered := []models.User{}
+	for _, c := range candidates {
+		if pred(c) {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered
+}
+
+// preferBy делит каждую группу на кандидатов, удовлетворяющих pred, и остальных.
+// Порядок исходных групп сохраняется, поэтому ранее примененные критерии важнее
+func preferBy(groups [][]models.User, pred func(models.User) bool) [][]models.User {
+	result := make([][]models.User, 0, len(groups)*2)
+	for _, group := range groups {
+		var preferred, rest []models.User
+		for _, c := range group {
+			if pred(c) {
+				preferred = append(preferred, c)
+			} else {
+				rest = append(rest, c)
+			}
+		}
+		result = append(result, preferred, rest)
+	}
+	return result
+}
+
+// splitByTags делит кандидатов на тех, чьи теги покрывают все требуемые, и остальных.
+// Без требуемых тегов подходят все
+func splitByTags(candidates []models.User, required []string) [][]models.User {
+	return preferTags([][]models.User{candidates}, required)
+}
+
+func preferTags(groups [][]models.User, required []string) [][]models.User {
+	if len(required) == 0 {
+		return groups
+	}
+	return preferBy(groups, func(u models.User) bool {
+		return hasAllTags(u.Tags, required)
+	})
+}
+
+func isSenior(u models.User) bool {
+	return u.Level == models.LevelSenior || u.Level == models.LevelLead
+}
+
+func containsSenior(users []models.User) bool {
+	for _, u := range users {
+		if isSenior(u) {
+			return true
+		}
+	}
+	return false
+}
+
+// тегов у участника и PR единицы, поэтому простой перебор быстрее карты
+func hasAllTags(tags, required []string) bool {
+	for _, t := range required {
+		if !slices.Contains(tags, t) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeTags приводит теги к нижнему регистру, убирает пустые и повторы.
+// nil остается nil, чтобы при обновлении команды отличать "не менять" от "очистить"
+func normalizeTags(tags []string) []string {
+	if tags == nil {
+		return nil
+	}
+
+	seen := make(map[string]bool, len(tags))
+	normalized := []string{}
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		normalized = append(normalized, t)
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+// normalizeMembers нормализует теги, уровни и расписание участников и проверяет их
+func normalizeMembers(members []models.TeamMember) error {
+	for i := range members {
+		members[i].Tags = normalizeTags(members[i].Tags)
+		if err := normalizeSchedule(&members[i]); err != nil {
+			return err
+		}
+
+		level := strings.ToLower(strings.TrimSpace(members[i].Level))
+		switch level {
+		case "", models.LevelJunior, models.LevelMiddle, models.LevelSenior, models.LevelLead:
+			members[i].Level = level
+		default:
+			return fmt.Errorf("%s: unknown level %q for user %s", models.ErrCodeBadRequest, members[i].Level, members[i].UserID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"pr-reviewer-service/internal/models"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tests := []struct {
+		name string
+		tags []string
+		want []string
+	}{
+		{name: "nil остается nil", tags: nil, want: nil},
+		{name: "пустой список", tags: []string{}, want: []string{}},
+		{name: "регистр, пробелы и повторы", tags: []string{" Go", "postgres", "go", ""}, want: []string{"go", "postgres"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeTags(tt.tags); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("normalizeTags(%v) = %#v, want %#v", tt.tags, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitByTags(t *testing.T) {
+	candidates := []models.User{
+		{UserID: "u1", Username: "User1", TeamName: "backend", IsActive: true, Tags: []string{"go", "postgres"}},
+		{UserID: "u2", Username: "User2", TeamName: "backend", IsActive: true, Tags: []string{"go"}},
+		{UserID: "u3", Username: "User3", TeamName: "backend", IsActive: true},
+	}
+
+	groups := splitByTags(candidates, nil)
+	if len(groups) != 1 || len(groups[0]) != 3 {
+		t.Fatalf("without required tags expected single group of all candidates, got %v", groups)
+	}
+
+	groups = splitByTags(candidates, []string{"go", "postgres"})
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups, got %d", len(groups))
+	}
+	if len(groups[0]) != 1 || groups[0][0].UserID != "u1" {
+		t.Errorf("expected only u1 to cover required tags, got %v", groups[0])
+	}
+	if len(groups[1]) != 2 {
+		t.Errorf("expected u2 and u3 as fallback, got %v", groups[1])
+	}
+
+	// никто не подходит - все кандидаты остаются в запасной группе
+	groups = splitByTags(candidates, []string{"frontend"})
+	if len(groups[0]) != 0 || len(groups[1]) != 3 {
+		t.Errorf("expected no matching candidates and 3 fallback, got %v", groups)
+	}
+}
+
+func TestPreferBy(t *testing.T) {
+	groups := [][]models.User{
+		{
+			{UserID: "u1", Level: models.LevelJunior, Tags: []string{"go"}},
+			{UserID: "u2", Level: models.LevelSenior},
+		},
+		{
+			{UserID: "u3", Level: models.LevelLead},
+			{UserID: "u4", Level: models.LevelMiddle},
+		},
+	}
+
+	got := preferBy(groups, isSenior)
+	want := [][]string{{"u2"}, {"u1"}, {"u3"}, {"u4"}}
+
+	if len(got) != len(want) {
+		t.Fatalf("expected %d groups, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(userIDs(got[i]), want[i]) {
+			t.Errorf("group %d = %v, want %v", i, userIDs(got[i]), want[i])
+		}
+	}
+}
+
+func TestNormalizeMembers(t *testing.T) {
+	members := []models.TeamMember{
+		{UserID: "u1", Level: " Senior "},
+		{UserID: "u2"},
+	}
+	if err := normalizeMembers(members); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if members[0].Level != models.LevelSenior || members[1].Level != "" {
+		t.Errorf("unexpected levels after normalization: %q, %q", members[0].Level, members[1].Level)
+	}
+
+	err := normalizeMembers([]models.TeamMember{{UserID: "u3", Level: "principal"}})
+	if err == nil || !strings.HasPrefix(err.Error(), models.ErrCodeBadRequest) {
+		t.Errorf("expected %s error for unknown level, got %v", models.ErrCodeBadRequest, err)
+	}
+}
+
+func TestAtCapacity(t *testing.T) {
+	two, zero := 2, 0
+	load := map[string]int{"u1": 2, "u2": 1}
+
+	tests := []struct {
+		user models.User
+		want bool
+	}{
+		{user: models.User{UserID: "u1"}, want: false},
+		{user: models.User{UserID: "u1", MaxOpenReviews: &two}, want: true},
+		{user: models.User{UserID: "u2", MaxOpenReviews: &two}, want: false},
+		{user: models.User{UserID: "u3", MaxOpenReviews: &zero}, want: true},
+	}
+
+	for _, tt := range tests {
+		if got := atCapacity(tt.user, load); got != tt.want {
+			t.Errorf("atCapacity(%s, limit %v) = %v, want %v", tt.user.UserID, tt.user.MaxOpenReviews, got, tt.want)
+		}
+	}
+}
+
+func TestPreferFresh(t *testing.T) {
+	groups := [][]models.User{
+		{{UserID: "u1"}, {UserID: "u2"}},
+		{{UserID: "u3"}, {UserID: "u4"}},
+	}
+
+	// без истории группы не меняются
+	if got := preferFresh(groups, nil); len(got) != 2 {
+		t.Fatalf("expected groups unchanged without history, got %d groups", len(got))
+	}
+
+	got := preferFresh(groups, map[string]bool{"u1": true, "u4": true})
+	want := [][]string{{"u2"}, {"u1"}, {"u3"}, {"u4"}}
+
+	if len(got) != len(want) {
+		t.Fatalf("expected %d groups, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(userIDs(got[i]), want[i]) {
+			t.Errorf("group %d = %v, want %v", i, userIDs(got[i]), want[i])
+		}
+	}
+}
+
+func TestOrderCandidates_AvailabilityBeforeFreshness(t *testing.T) {
+	office := &models.WorkingHours{Start: "09:00", End: "18:00", Days: []int{1, 2, 3, 4, 5}}
+	// 08:00 UTC: в Москве 11:00, в Нью-Йорке 04:00
+	now := time.Date(2025, 7, 2, 8, 0, 0, 0, time.UTC)
+
+	fresh := models.User{UserID: "fresh", Timezone: "America/New_York", WorkingHours: office}
+	recent := models.User{UserID: "recent", Timezone: "Europe/Moscow", WorkingHours: office}
+	groups := orderCandidates([][]models.User{{fresh, recent}}, map[string]bool{"recent": true}, now)
+
+	// стратегия берет кандидатов из первых непустых групп
+	picked := selectFromGroups(groups, 1, func(group []models.User, n int) []models.User {
+		return group[:n]
+	})
+	if len(picked) != 1 || picked[0].UserID != "recent" {
+		t.Errorf("expected recent reviewer in working hours to win, got %v", userIDs(picked))
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"sort"
+	"strings"
+	"time"
 
 	"pr-reviewer-service/internal/logger"
 	"pr-reviewer-service/internal/models"
@@ -12,19 +15,34 @@ import (
 )
 
 type Service struct {
-	repo   *repository.Repository
-	logger *logger.Logger
+	repo       *repository.Repository
+	logger     *logger.Logger
+	strategies map[string]ReviewerStrategy
 }
 
 func New(repo *repository.Repository, log *logger.Logger) *Service {
 	return &Service{
-		repo:   repo,
-		logger: log,
+		repo:       repo,
+		logger:     log,
+		strategies: defaultStrategies(repo),
 	}
 }
 
 func (s *Service) CreateTeam(ctx context.Context, req models.CreateTeamRequest) (*models.Team, error) {
-	if err := s.repo.CreateTeam(ctx, req.TeamName, req.Members); err != nil {
+	if err := normalizeMembers(req.Members); err != nil {
+		return nil, err
+	}
+
+	settings, err := s.applyTeamSettings(models.TeamSettings{
+		AssignmentStrategy: models.DefaultStrategy,
+		ReviewersPerPR:     models.DefaultReviewersPerPR,
+		SLAGraceHours:      models.DefaultSLAGraceHours,
+	}, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateTeam(ctx, req.TeamName, settings, req.Members); err != nil {
 		return nil, err
 	}
 
@@ -32,13 +50,120 @@ func (s *Service) CreateTeam(ctx context.Context, req models.CreateTeamRequest)
 }
 
 func (s *Service) UpdateTeam(ctx context.Context, req models.CreateTeamRequest) (*models.Team, error) {
-	if err := s.repo.UpdateTeam(ctx, req.TeamName, req.Members); err != nil {
+	if err := normalizeMembers(req.Members); err != nil {
+		return nil, err
+	}
+
+	current, err := s.repo.GetTeamSettings(ctx, req.TeamName)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("%s: team not found", models.ErrCodeNotFound)
+		}
+		return nil, err
+	}
+
+	settings, err := s.applyTeamSettings(*current, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateTeam(ctx, req.TeamName, settings, req.Members); err != nil {
 		return nil, err
 	}
 
 	return s.repo.GetTeam(ctx, req.TeamName)
 }
 
+// накладывает заданные в запросе настройки на текущие и проверяет их
+func (s *Service) applyTeamSettings(settings models.TeamSettings, req models.CreateTeamRequest) (models.TeamSettings, error) {
+	if req.AssignmentStrategy != "" {
+		if _, ok := s.strategies[req.AssignmentStrategy]; !ok {
+			return settings, fmt.Errorf("%s: unknown assignment_strategy %q", models.ErrCodeBadRequest, req.AssignmentStrategy)
+		}
+		settings.AssignmentStrategy = req.AssignmentStrategy
+	}
+
+	if req.RequireSenior != nil {
+		settings.RequireSenior = *req.RequireSenior
+	}
+
+	if req.ReviewersPerPR != nil {
+		if *req.ReviewersPerPR < 1 || *req.ReviewersPerPR > models.MaxReviewersPerPR {
+			return settings, fmt.Errorf("%s: reviewers_per_pr must be between 1 and %d", models.ErrCodeBadRequest, models.MaxReviewersPerPR)
+		}
+		settings.ReviewersPerPR = *req.ReviewersPerPR
+	}
+
+	if req.PairingLookback != nil {
+		if *req.PairingLookback < 0 || *req.PairingLookback > models.MaxPairingLookback {
+			return settings, fmt.Errorf("%s: pairing_lookback must be between 0 and %d", models.ErrCodeBadRequest, models.MaxPairingLookback)
+		}
+		settings.PairingLookback = *req.PairingLookback
+	}
+
+	if req.ReviewSLAHours != nil {
+		if *req.ReviewSLAHours < 0 || *req.ReviewSLAHours > models.MaxSLAHours {
+			return settings, fmt.Errorf("%s: review_sla_hours must be between 0 and %d", models.ErrCodeBadRequest, models.MaxSLAHours)
+		}
+		settings.ReviewSLAHours = *req.ReviewSLAHours
+	}
+
+	if req.SLAGraceHours != nil {
+		if *req.SLAGraceHours < 0 || *req.SLAGraceHours > models.MaxSLAHours {
+			return settings, fmt.Errorf("%s: sla_grace_hours must be between 0 and %d", models.ErrCodeBadRequest, models.MaxSLAHours)
+		}
+		settings.SLAGraceHours = *req.SLAGraceHours
+	}
+
+	if req.RequiredApprovals != nil {
+		if *req.RequiredApprovals < 0 || *req.RequiredApprovals > models.MaxReviewersPerPR {
+			return settings, fmt.Errorf("%s: required_approvals must be between 0 and %d",
+				models.ErrCodeBadRequest, models.MaxReviewersPerPR)
+		}
+		settings.RequiredApprovals = *req.RequiredApprovals
+	}
+
+	if req.AssignmentRules != nil {
+		rules, err := s.validateAssignmentRules(req.AssignmentRules)
+		if err != nil {
+			return settings, err
+		}
+		settings.AssignmentRules = rules
+	}
+
+	if req.ReassignOnDeactivate != nil {
+		settings.ReassignOnDeactivate = *req.ReassignOnDeactivate
+	}
+
+	if req.FallbackTeams != nil {
+		fallbackTeams, err := normalizeFallbackTeams(req.TeamName, req.FallbackTeams)
+		if err != nil {
+			return settings, err
+		}
+		settings.FallbackTeams = fallbackTeams
+	}
+
+	return settings, nil
+}
+
+// normalizeFallbackTeams убирает пустые имена и повторы, сохраняя порядок приоритета
+func normalizeFallbackTeams(teamName string, fallbackTeams []string) ([]string, error) {
+	seen := make(map[string]bool, len(fallbackTeams))
+	normalized := []string{}
+	for _, name := range fallbackTeams {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		if name == teamName {
+			return nil, fmt.Errorf("%s: team cannot be its own fallback team", models.ErrCodeBadRequest)
+		}
+		seen[name] = true
+		normalized = append(normalized, name)
+	}
+	return normalized, nil
+}
+
 func (s *Service) GetTeam(ctx context.Context, teamName string) (*models.Team, error) {
 	team, err := s.repo.GetTeam(ctx, teamName)
 	if err != nil {
@@ -50,8 +175,216 @@ func (s *Service) GetTeam(ctx context.Context, teamName string) (*models.Team, e
 	return team, nil
 }
 
-func (s *Service) SetUserActive(ctx context.Context, req models.SetIsActiveRequest) (*models.User, error) {
-	user, err := s.repo.SetUserActive(ctx, req.UserID, req.IsActive)
+func (s *Service) SetCodeowners(ctx context.Context, req models.SetCodeownersRequest) (*models.TeamCodeowners, error) {
+	rules, err := parseCodeowners(req.Content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid CODEOWNERS: %w", models.ErrCodeBadRequest, err)
+	}
+
+	if err := s.repo.SetCodeowners(ctx, req.TeamName, req.Content); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("%s: team not found", models.ErrCodeNotFound)
+		}
+		return nil, err
+	}
+
+	s.logger.Info("CODEOWNERS updated for team %s: %d rules", req.TeamName, len(rules))
+	return toTeamCodeowners(req.TeamName, req.Content, rules), nil
+}
+
+func (s *Service) GetCodeowners(ctx context.Context, teamName string) (*models.TeamCodeowners, error) {
+	content, err := s.repo.GetCodeowners(ctx, teamName)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("%s: CODEOWNERS not found for team", models.ErrCodeNotFound)
+		}
+		return nil, err
+	}
+
+	rules, err := parseCodeowners(content)
+	if err != nil {
+		return nil, err
+	}
+	return toTeamCodeowners(teamName, content, rules), nil
+}
+
+func toTeamCodeowners(teamName, content string, rules []codeownersRule) *models.TeamCodeowners {
+	result := &models.TeamCodeowners{
+		TeamName: teamName,
+		Content:  content,
+		Rules:    make([]models.CodeownersRule, len(rules)),
+	}
+	for i, rule := range rules {
+		result.Rules[i] = rule.CodeownersRule
+	}
+	return result
+}
+
+// SetUserActive меняет активность пользователя. Если при деактивации включено переназначение
+// (параметром запроса или настройкой команды), возвращает также замены на его открытых PR
+func (s *Service) SetUserActive(ctx context.Context, req models.SetIsActiveRequest) (*models.User, []models.PRReassignment, error) {
+	reassign := false
+	if !req.IsActive {
+		var err error
+		if reassign, err = s.reassignOnDeactivate(ctx, req); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if !reassign {
+		user, err := s.repo.SetUserActive(ctx, req.UserID, req.IsActive)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return nil, nil, fmt.Errorf("%s: %w", models.ErrCodeNotFound, err)
+			}
+			return nil, nil, err
+		}
+		return user, nil, nil
+	}
+
+	var user *models.User
+	var reassignments []models.PRReassignment
+	err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = s.repo.SetUserActive(ctx, req.UserID, false)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return fmt.Errorf("%s: %w", models.ErrCodeNotFound, err)
+			}
+			return err
+		}
+
+		reassignments, err = s.reassignOpenReviews(ctx, user)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.logger.Info("User %s deactivated, reviewers reassigned on %d open PRs", user.UserID, len(reassignments))
+
+	return user, reassignments, nil
+}
+
+// reassignOnDeactivate - нужно ли при деактивации переназначать открытые ревью пользователя
+func (s *Service) reassignOnDeactivate(ctx context.Context, req models.SetIsActiveRequest) (bool, error) {
+	if req.Reassign != nil {
+		return *req.Reassign, nil
+	}
+
+	user, err := s.repo.GetUser(ctx, req.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return false, fmt.Errorf("%s: %w", models.ErrCodeNotFound, err)
+		}
+		return false, err
+	}
+	settings, err := s.repo.GetTeamSettings(ctx, user.TeamName)
+	if err != nil {
+		return false, err
+	}
+	return settings.ReassignOnDeactivate, nil
+}
+
+// reassignOpenReviews заменяет деактивированного пользователя на всех открытых PR так же, как ReassignReviewer.
+// Если заменить некем, пользователь снимается с PR и место ревьювера остается свободным
+func (s *Service) reassignOpenReviews(ctx context.Context, user *models.User) ([]models.PRReassignment, error) {
+	prs, err := s.repo.GetOpenPRsReviewedBy(ctx, []string{user.UserID})
+	if err != nil {
+		return nil, err
+	}
+
+	reassignments := []models.PRReassignment{}
+	for i := range prs {
+		pr := &prs[i]
+		replacement := models.ReviewerReplacement{OldUserID: user.UserID}
+
+		newReviewerID, err := s.replaceReviewer(ctx, pr, user, models.AssignmentReassign)
+		switch {
+		case err == nil:
+			replacement.NewUserID = newReviewerID
+		case hasErrorCode(err, models.ErrCodeNoCandidate), hasErrorCode(err, models.ErrCodeNoCapacity):
+			s.logger.Warn("No replacement for %s on PR %s, leaving the slot empty: %v", user.UserID, pr.PullRequestID, err)
+			if err := s.repo.RemoveReviewer(ctx, pr.PullRequestID, user.UserID); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, err
+		}
+
+		reassignments = append(reassignments, models.PRReassignment{
+			PullRequestID: pr.PullRequestID,
+			Replacements:  []models.ReviewerReplacement{replacement},
+		})
+	}
+
+	return reassignments, nil
+}
+
+// hasErrorCode - ошибка сервиса с указанным кодом
+func hasErrorCode(err error, code string) bool {
+	return strings.HasPrefix(err.Error(), code+":")
+}
+
+func (s *Service) AddOutOfOffice(ctx context.Context, req models.AddOutOfOfficeRequest) (*models.OutOfOffice, error) {
+	if err := validateOutOfOffice(req); err != nil {
+		return nil, err
+	}
+
+	period, err := s.repo.AddOutOfOffice(ctx, req)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("%s: user not found", models.ErrCodeNotFound)
+		}
+		return nil, err
+	}
+
+	s.logger.Info("User %s is out of office from %s to %s", req.UserID,
+		period.StartsAt.Format(time.RFC3339), period.EndsAt.Format(time.RFC3339))
+	return period, nil
+}
+
+// проверяет обязательные поля и что период не пустой
+func validateOutOfOffice(req models.AddOutOfOfficeRequest) error {
+	if req.UserID == "" {
+		return fmt.Errorf("%s: user_id is required", models.ErrCodeBadRequest)
+	}
+	if req.StartsAt.IsZero() || req.EndsAt.IsZero() {
+		return fmt.Errorf("%s: starts_at and ends_at are required", models.ErrCodeBadRequest)
+	}
+	if !req.EndsAt.After(req.StartsAt) {
+		return fmt.Errorf("%s: ends_at must be after starts_at", models.ErrCodeBadRequest)
+	}
+	return nil
+}
+
+// текущие и будущие периоды отсутствия пользователя
+func (s *Service) GetOutOfOffice(ctx context.Context, userID string) ([]models.OutOfOffice, error) {
+	if _, err := s.repo.GetUser(ctx, userID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("%s: user not found", models.ErrCodeNotFound)
+		}
+		return nil, err
+	}
+	return s.repo.GetOutOfOffice(ctx, userID)
+}
+
+func (s *Service) DeleteOutOfOffice(ctx context.Context, req models.DeleteOutOfOfficeRequest) error {
+	if err := s.repo.DeleteOutOfOffice(ctx, req.ID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return fmt.Errorf("%s: out of office period not found", models.ErrCodeNotFound)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *Service) SetUserMaxOpenReviews(ctx context.Context, req models.SetMaxOpenReviewsRequest) (*models.User, error) {
+	if req.MaxOpenReviews != nil && *req.MaxOpenReviews < 0 {
+		return nil, fmt.Errorf("%s: max_open_reviews must not be negative", models.ErrCodeBadRequest)
+	}
+
+	user, err := s.repo.SetUserMaxOpenReviews(ctx, req.UserID, req.MaxOpenReviews)
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
 			return nil, fmt.Errorf("%s: %w", models.ErrCodeNotFound, err)
@@ -61,17 +394,25 @@ func (s *Service) SetUserActive(ctx context.Context, req models.SetIsActiveReque
 	return user, nil
 }
 
-func (s *Service) GetUserReviews(ctx context.Context, userID string) ([]models.PullRequestShort, error) {
+// GetUserReviews возвращает PR, где пользователь назначен ревьювером, списком и по репозиториям
+func (s *Service) GetUserReviews(ctx context.Context, userID string) ([]models.PullRequestShort, []models.RepositoryReviews, error) {
 	prs, err := s.repo.GetPRsByReviewer(ctx, userID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return prs, nil
+	return prs, groupByRepository(prs), nil
 }
 
 func (s *Service) CreatePR(ctx context.Context, req models.CreatePRRequest) (*models.PullRequest, error) {
 	s.logger.Debug("Creating PR: %s by author: %s", req.PullRequestID, req.AuthorID)
 
+	if err := normalizePRMetadata(&req); err != nil {
+		return nil, err
+	}
+	if err := normalizePRKey(&req); err != nil {
+		return nil, err
+	}
+
 	// получаем автора чтобы узнать его команду
 	author, err := s.repo.GetUser(ctx, req.AuthorID)
 	if err != nil {
@@ -82,37 +423,153 @@ func (s *Service) CreatePR(ctx context.Context, req models.CreatePRRequest) (*mo
 		return nil, err
 	}
 
-	// получаем активных участников команды кроме автора
-	candidates, err := s.repo.GetActiveTeamMembers(ctx, author.TeamName, author.UserID)
+	repo, err := s.prRepository(ctx, req.Repository)
 	if err != nil {
 		return nil, err
 	}
-
-	// выбираем до 2 случайных ревьюверов
-	reviewers := selectRandomReviewers(candidates, 2)
-	reviewerIDs := make([]string, len(reviewers))
-	for i, r := range reviewers {
-		reviewerIDs[i] = r.UserID
+	if err := checkRepositoryOwner(author, repo); err != nil {
+		return nil, err
 	}
 
-	s.logger.Info("Assigned %d reviewers to PR %s: %v", len(reviewerIDs), req.PullRequestID, reviewerIDs)
+	if req.Draft {
+		return s.createDraftPR(ctx, req)
+	}
 
-	pr := &models.PullRequest{
-		PullRequestID:     req.PullRequestID,
-		PullRequestName:   req.PullRequestName,
-		AuthorID:          req.AuthorID,
-		Status:            models.StatusOpen,
-		AssignedReviewers: reviewerIDs,
+	settings, err := s.prSettings(ctx, req.PullRequestID, author, repo, req.PRMetadata)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := s.repo.CreatePR(ctx, pr, reviewerIDs); err != nil {
+	// выбор ревьюверов и создание PR выполняются в одной транзакции,
+	// чтобы очередь round_robin сдвигалась только вместе с созданным PR
+	err = s.repo.WithTx(ctx, func(ctx context.Context) error {
+		assigned, err := s.selectInitialReviewers(ctx, newSelectionTrace(settings.AssignmentStrategy), author, settings, req)
+		if err != nil {
+			return err
+		}
+		reviewerIDs := userIDs(assigned.reviewers)
+
+		s.logger.Info("Assigned %d reviewers to PR %s using %s strategy: %v",
+			len(reviewerIDs), req.PullRequestID, settings.AssignmentStrategy, reviewerIDs)
+		if len(assigned.fallbackTeams) > 0 {
+			s.logger.Info("PR %s reviewers from fallback teams: %v", req.PullRequestID, assigned.fallbackTeams)
+		}
+
+		pr := &models.PullRequest{
+			PullRequestID:     req.PullRequestID,
+			Repository:        req.Repository,
+			Number:            req.Number,
+			PullRequestName:   req.PullRequestName,
+			AuthorID:          req.AuthorID,
+			Status:            models.StatusOpen,
+			AssignedReviewers: reviewerIDs,
+			RequiredTags:      assigned.requiredTags,
+			ChangedFiles:      req.ChangedFiles,
+			OwnershipMatch:    assigned.ownership,
+			PRMetadata:        req.PRMetadata,
+		}
+
+		if err := s.repo.CreatePR(ctx, pr, assigned.reviewerInfos()); err != nil {
+			return err
+		}
+
+		_, err = s.saveExplanation(ctx, assigned.trace, req.PullRequestID, models.AssignmentInitial, reviewerIDs)
+		return err
+	})
+	if err != nil {
 		if errors.Is(err, repository.ErrAlreadyExists) {
 			return nil, fmt.Errorf("%s: %w", models.ErrCodePRExists, err)
 		}
 		return nil, err
 	}
 
-	return s.repo.GetPR(ctx, req.PullRequestID)
+	return s.getAssignedPR(ctx, req.PullRequestID)
+}
+
+// PreviewPR выполняет тот же выбор ревьюверов, что и CreatePR, но ничего не сохраняет:
+// PR не создается, объяснение не записывается, очередь round_robin не сдвигается
+func (s *Service) PreviewPR(ctx context.Context, req models.CreatePRRequest) (*models.AssignmentPreview, error) {
+	if err := normalizePRMetadata(&req); err != nil {
+		return nil, err
+	}
+	if err := normalizePreviewKey(&req); err != nil {
+		return nil, err
+	}
+
+	author, err := s.repo.GetUser(ctx, req.AuthorID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("%s: author not found: %w", models.ErrCodeNotFound, err)
+		}
+		return nil, err
+	}
+
+	repo, err := s.prRepository(ctx, req.Repository)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRepositoryOwner(author, repo); err != nil {
+		return nil, err
+	}
+
+	settings, err := s.prSettings(ctx, req.PullRequestID, author, repo, req.PRMetadata)
+	if err != nil {
+		return nil, err
+	}
+
+	trace := newSelectionTrace(settings.AssignmentStrategy)
+	trace.dryRun = true
+
+	assigned, err := s.selectInitialReviewers(ctx, trace, author, settings, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.excludeUnavailable(ctx, trace); err != nil {
+		return nil, err
+	}
+
+	reviewers := assigned.reviewerInfos()
+	now := time.Now()
+	for i, u := range assigned.reviewers {
+		reviewers[i].NextWorkingWindow = nextWorkingWindow(u, now)
+	}
+
+	explanation := trace.explain("", models.AssignmentInitial, userIDs(assigned.reviewers))
+	return &models.AssignmentPreview{
+		AuthorID:       author.UserID,
+		Reviewers:      reviewers,
+		RequiredTags:   assigned.requiredTags,
+		OwnershipMatch: assigned.ownership,
+		Strategy:       explanation.Strategy,
+		CandidatePool:  explanation.CandidatePool,
+		Excluded:       explanation.Excluded,
+	}, nil
+}
+
+// getAssignedPR возвращает PR, дополняя ревьюверов их ближайшим рабочим окном
+func (s *Service) getAssignedPR(ctx context.Context, prID string) (*models.PullRequest, error) {
+	pr, err := s.repo.GetPR(ctx, prID)
+	if err != nil {
+		return nil, err
+	}
+
+	reviewers, err := s.repo.GetUsers(ctx, pr.AssignedReviewers)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]models.User, len(reviewers))
+	for _, u := range reviewers {
+		byID[u.UserID] = u
+	}
+
+	now := time.Now()
+	for i := range pr.Reviewers {
+		if u, ok := byID[pr.Reviewers[i].UserID]; ok {
+			pr.Reviewers[i].NextWorkingWindow = nextWorkingWindow(u, now)
+		}
+	}
+
+	return pr, nil
 }
 
 func (s *Service) MergePR(ctx context.Context, req models.MergePRRequest) (*models.PullRequest, error) {
@@ -129,6 +586,12 @@ func (s *Service) MergePR(ctx context.Context, req models.MergePRRequest) (*mode
 	if pr.Status == models.StatusMerged {
 		return pr, nil
 	}
+	if err := prStatusError(pr, "merge"); err != nil {
+		return nil, err
+	}
+	if err := s.checkApprovals(ctx, pr); err != nil {
+		return nil, err
+	}
 
 	return s.repo.MergePR(ctx, req.PullRequestID)
 }
@@ -142,9 +605,9 @@ func (s *Service) ReassignReviewer(ctx context.Context, req models.ReassignReque
 		return nil, "", err
 	}
 
-	// нельзя переназначать на merged PR
-	if pr.Status == models.StatusMerged {
-		return nil, "", fmt.Errorf("%s: cannot reassign on merged PR", models.ErrCodePRMerged)
+	// переназначать можно только на открытом PR
+	if err := prStatusError(pr, "reassign on"); err != nil {
+		return nil, "", err
 	}
 
 	// проверяем что пользователь действительно назначен ревьювером
@@ -156,6 +619,10 @@ func (s *Service) ReassignReviewer(ctx context.Context, req models.ReassignReque
 		return nil, "", fmt.Errorf("%s: reviewer is not assigned to this PR", models.ErrCodeNotAssigned)
 	}
 
+	if req.NewUserID != "" {
+		return s.reassignToUser(ctx, pr, req)
+	}
+
 	// получаем старого ревьювера чтобы узнать его команду
 	oldReviewer, err := s.repo.GetUser(ctx, req.OldUserID)
 	if err != nil {
@@ -165,18 +632,46 @@ func (s *Service) ReassignReviewer(ctx context.Context, req models.ReassignReque
 		return nil, "", err
 	}
 
+	newReviewerID, err := s.replaceReviewer(ctx, pr, oldReviewer, models.AssignmentReassign)
+	if err != nil {
+		return nil, "", err
+	}
+
+	updatedPR, err := s.getAssignedPR(ctx, req.PullRequestID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return updatedPR, newReviewerID, nil
+}
+
+// replaceReviewer заменяет ревьювера PR участником его команды, выбранным стратегией команды,
+// и сохраняет объяснение с указанным kind. Если заменить некем, возвращает NO_CANDIDATE или NO_CAPACITY
+func (s *Service) replaceReviewer(ctx context.Context, pr *models.PullRequest, oldReviewer *models.User, kind string) (string, error) {
+	settings, err := s.repo.GetTeamSettings(ctx, oldReviewer.TeamName)
+	if err != nil {
+		return "", err
+	}
+
 	// берем активных участников из команды старого ревьювера
 	candidates, err := s.repo.GetActiveTeamMembers(ctx, oldReviewer.TeamName, "")
 	if err != nil {
-		return nil, "", err
+		return "", err
 	}
 
+	trace := newSelectionTrace(settings.AssignmentStrategy)
+	trace.addTeam(oldReviewer.TeamName)
+	trace.consider(candidates)
+
 	// исключаем: старого ревьювера, текущих ревьюверов PR, автора PR
 	excludeMap := make(map[string]bool)
-	excludeMap[req.OldUserID] = true
+	excludeMap[oldReviewer.UserID] = true
+	trace.exclude(oldReviewer.UserID, models.ExclusionReplaced)
 	excludeMap[pr.AuthorID] = true
+	trace.exclude(pr.AuthorID, models.ExclusionAuthor)
 	for _, reviewerID := range pr.AssignedReviewers {
 		excludeMap[reviewerID] = true
+		trace.exclude(reviewerID, models.ExclusionAlreadyAssigned)
 	}
 
 	filtered := []models.User{}
@@ -187,22 +682,83 @@ func (s *Service) ReassignReviewer(ctx context.Context, req models.ReassignReque
 	}
 
 	if len(filtered) == 0 {
-		return nil, "", fmt.Errorf("%s: no active replacement candidate in team", models.ErrCodeNoCandidate)
+		return "", fmt.Errorf("%s: no active replacement candidate in team", models.ErrCodeNoCandidate)
+	}
+
+	// единственного senior на PR можно заменить только другим senior
+	if settings.RequireSenior && isSenior(*oldReviewer) {
+		onlySenior, err := s.isOnlySeniorReviewer(ctx, pr, oldReviewer.UserID)
+		if err != nil {
+			return "", err
+		}
+		if onlySenior {
+			for _, c := range filtered {
+				if !isSenior(c) {
+					trace.exclude(c.UserID, models.ExclusionNotSenior)
+				}
+			}
+			filtered = filterUsers(filtered, isSenior)
+			if len(filtered) == 0 {
+				return "", fmt.Errorf("%s: no active senior replacement candidate in team", models.ErrCodeNoCandidate)
+			}
+		}
 	}
 
-	// выбираем случайного кандидата
-	newReviewer := filtered[rand.Intn(len(filtered))]
+	var newReviewer models.User
+	err = s.repo.WithTx(ctx, func(ctx context.Context) error {
+		// выбираем кандидата стратегией команды
+		groups := splitByTags(filtered, pr.RequiredTags)
+		selected, err := s.selectReviewers(ctx, trace, oldReviewer.TeamName, settings.AssignmentStrategy, groups, nil, 1)
+		if err != nil {
+			return err
+		}
+		if len(selected) == 0 {
+			return fmt.Errorf("%s: all replacement candidates reached max_open_reviews", models.ErrCodeNoCapacity)
+		}
+		newReviewer = selected[0]
 
-	if err := s.repo.ReassignReviewer(ctx, req.PullRequestID, req.OldUserID, newReviewer.UserID); err != nil {
-		return nil, "", err
+		if err := s.repo.ReassignReviewer(ctx, pr.PullRequestID, oldReviewer.UserID, newReviewer.UserID); err != nil {
+			return err
+		}
+
+		_, err = s.saveExplanation(ctx, trace, pr.PullRequestID, kind, []string{newReviewer.UserID})
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return newReviewer.UserID, nil
+}
+
+// является ли userID единственным senior среди ревьюверов PR
+func (s *Service) isOnlySeniorReviewer(ctx context.Context, pr *models.PullRequest, userID string) (bool, error) {
+	others := []string{}
+	for _, id := range pr.AssignedReviewers {
+		if id != userID {
+			others = append(others, id)
+		}
+	}
+	if len(others) == 0 {
+		return true, nil
 	}
 
-	updatedPR, err := s.repo.GetPR(ctx, req.PullRequestID)
+	reviewers, err := s.repo.GetUsers(ctx, others)
 	if err != nil {
-		return nil, "", err
+		return false, err
 	}
+	return !containsSenior(reviewers), nil
+}
 
-	return updatedPR, newReviewer.UserID, nil
+// объяснения всех назначений ревьюверов PR
+func (s *Service) GetAssignmentExplanations(ctx context.Context, prID string) ([]models.AssignmentExplanation, error) {
+	if _, err := s.repo.GetPR(ctx, prID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("%s: PR not found", models.ErrCodeNotFound)
+		}
+		return nil, err
+	}
+	return s.repo.GetAssignmentExplanations(ctx, prID)
 }
 
 func (s *Service) HealthCheck(ctx context.Context) error {
@@ -212,10 +768,48 @@ func (s *Service) HealthCheck(ctx context.Context) error {
 }
 
 func (s *Service) GetStats(ctx context.Context) ([]models.UserStats, error) {
-	return s.repo.GetUserStats(ctx)
+	stats, err := s.repo.GetUserStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range stats {
+		stats[i].RemainingCapacity = remainingCapacity(stats[i].MaxOpenReviews, stats[i].ActiveReviews)
+		stats[i].DeclineRate = declineRate(stats[i].TotalDeclines, stats[i].TotalReviews)
+	}
+	return stats, nil
 }
 
-func selectRandomReviewers(candidates []models.User, maxCount int) []models.User {
+// сколько еще ревью можно назначить пользователю, nil - без ограничения
+func remainingCapacity(limit *int, openReviews int) *int {
+	if limit == nil {
+		return nil
+	}
+	remaining := max(*limit-openReviews, 0)
+	return &remaining
+}
+
+// выбирает до maxCount кандидатов с наименьшим числом открытых ревью,
+// при равной нагрузке порядок случайный
+func selectLeastLoadedReviewers(rng *rand.Rand, candidates []models.User, load map[string]int, maxCount int) []models.User {
+	// перемешиваем всех кандидатов, а затем стабильно сортируем по нагрузке
+	order := shuffledOrder(rng, len(candidates))
+	loads := make([]int, len(candidates))
+	for i, c := range candidates {
+		loads[i] = load[c.UserID]
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return loads[order[i]] < loads[order[j]]
+	})
+
+	selected := make([]models.User, min(maxCount, len(order)))
+	for i := range selected {
+		selected[i] = candidates[order[i]]
+	}
+	return selected
+}
+
+func selectRandomReviewers(rng *rand.Rand, candidates []models.User, maxCount int) []models.User {
 	if len(candidates) == 0 {
 		return []models.User{}
 	}
@@ -226,11 +820,23 @@ func selectRandomReviewers(candidates []models.User, maxCount int) []models.User
 	}
 
 	// перемешиваем кандидатов и берем первые count
-	shuffled := make([]models.User, len(candidates))
-	copy(shuffled, candidates)
-	rand.Shuffle(len(shuffled), func(i, j int) {
-		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
-	})
+	order := shuffledOrder(rng, len(candidates))
+	selected := make([]models.User, count)
+	for i := range selected {
+		selected[i] = candidates[order[i]]
+	}
+	return selected
+}
 
-	return shuffled[:count]
+// shuffledOrder возвращает индексы 0..n-1 в случайном порядке. Переставляются индексы, а не сами
+// пользователи: при массовой замене кандидатов сотни, и копирование структур заметно
+func shuffledOrder(rng *rand.Rand, n int) []int {
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	rng.Shuffle(n, func(i, j int) {
+		order[i], order[j] = order[j], order[i]
+	})
+	return order
 }
@@ -1,12 +1,19 @@
 package service
 
 import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"pr-reviewer-service/internal/models"
 )
 
 func TestSelectRandomReviewers(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
 	tests := []struct {
 		name       string
 		candidates []models.User
@@ -51,7 +58,7 @@ func TestSelectRandomReviewers(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := selectRandomReviewers(tt.candidates, tt.maxCount)
+			result := selectRandomReviewers(rng, tt.candidates, tt.maxCount)
 
 			if len(result) != tt.wantCount {
 				t.Errorf("selectRandomReviewers() returned %d reviewers, want %d", len(result), tt.wantCount)
@@ -82,6 +89,7 @@ func TestSelectRandomReviewers(t *testing.T) {
 }
 
 func TestSelectRandomReviewers_Randomness(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
 	// проверяем что функция действительно перемешивает кандидатов
 	candidates := []models.User{
 		{UserID: "u1", Username: "User1", TeamName: "backend", IsActive: true},
@@ -96,7 +104,7 @@ func TestSelectRandomReviewers_Randomness(t *testing.T) {
 	iterations := 100
 
 	for i := 0; i < iterations; i++ {
-		reviewers := selectRandomReviewers(candidates, 2)
+		reviewers := selectRandomReviewers(rng, candidates, 2)
 		if len(reviewers) != 2 {
 			t.Fatalf("expected 2 reviewers, got %d", len(reviewers))
 		}
@@ -171,3 +179,269 @@ func TestReassignmentExclusionLogic(t *testing.T) {
 		}
 	}
 }
+
+func TestSelectLeastLoadedReviewers(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	candidates := []models.User{
+		{UserID: "u1", Username: "User1", TeamName: "backend", IsActive: true},
+		{UserID: "u2", Username: "User2", TeamName: "backend", IsActive: true},
+		{UserID: "u3", Username: "User3", TeamName: "backend", IsActive: true},
+		{UserID: "u4", Username: "User4", TeamName: "backend", IsActive: true},
+	}
+	// u3 и u4 без открытых ревью, в карте их нет
+	load := map[string]int{"u1": 6, "u2": 1}
+
+	for i := 0; i < 50; i++ {
+		result := selectLeastLoadedReviewers(rng, candidates, load, 2)
+		if len(result) != 2 {
+			t.Fatalf("expected 2 reviewers, got %d", len(result))
+		}
+		for _, r := range result {
+			if r.UserID != "u3" && r.UserID != "u4" {
+				t.Fatalf("expected least loaded reviewers u3 and u4, got %s", r.UserID)
+			}
+		}
+	}
+
+	result := selectLeastLoadedReviewers(rng, candidates, load, 3)
+	if len(result) != 3 || result[2].UserID != "u2" {
+		t.Errorf("expected u2 as third reviewer, got %v", result)
+	}
+
+	if result := selectLeastLoadedReviewers(rng, []models.User{}, load, 2); len(result) != 0 {
+		t.Errorf("expected no reviewers for empty candidates, got %d", len(result))
+	}
+}
+
+func TestSelectLeastLoadedReviewers_TieBreak(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	// при одинаковой нагрузке выбор должен быть случайным
+	candidates := []models.User{
+		{UserID: "u1", Username: "User1", TeamName: "backend", IsActive: true},
+		{UserID: "u2", Username: "User2", TeamName: "backend", IsActive: true},
+		{UserID: "u3", Username: "User3", TeamName: "backend", IsActive: true},
+	}
+	load := map[string]int{"u1": 1, "u2": 1, "u3": 1}
+
+	results := make(map[string]int)
+	for i := 0; i < 100; i++ {
+		reviewers := selectLeastLoadedReviewers(rng, candidates, load, 1)
+		results[reviewers[0].UserID]++
+	}
+
+	if len(results) < 2 {
+		t.Errorf("selectLeastLoadedReviewers() does not break ties randomly, got %v", results)
+	}
+}
+
+func TestApplyTeamSettings(t *testing.T) {
+	s := &Service{strategies: defaultStrategies(nil)}
+	base := models.TeamSettings{
+		AssignmentStrategy: models.DefaultStrategy,
+		ReviewersPerPR:     models.DefaultReviewersPerPR,
+	}
+	intPtr := func(v int) *int { return &v }
+	enabled := true
+
+	tests := []struct {
+		name    string
+		req     models.CreateTeamRequest
+		want    models.TeamSettings
+		wantErr bool
+	}{
+		{
+			name: "без настроек остаются текущие",
+			req:  models.CreateTeamRequest{TeamName: "backend"},
+			want: base,
+		},
+		{
+			name: "смена стратегии и числа ревьюверов",
+			req: models.CreateTeamRequest{
+				TeamName:           "platform",
+				AssignmentStrategy: models.StrategyRoundRobin,
+				ReviewersPerPR:     intPtr(3),
+			},
+			want: models.TeamSettings{AssignmentStrategy: models.StrategyRoundRobin, ReviewersPerPR: 3},
+		},
+		{
+			name: "команды-партнеры без пустых и повторов",
+			req: models.CreateTeamRequest{
+				TeamName:      "backend",
+				FallbackTeams: []string{"platform", " ", "frontend", "platform"},
+			},
+			want: models.TeamSettings{
+				AssignmentStrategy: models.DefaultStrategy,
+				ReviewersPerPR:     models.DefaultReviewersPerPR,
+				FallbackTeams:      []string{"platform", "frontend"},
+			},
+		},
+		{
+			name: "учет последних PR автора",
+			req:  models.CreateTeamRequest{TeamName: "backend", PairingLookback: intPtr(5)},
+			want: models.TeamSettings{
+				AssignmentStrategy: models.DefaultStrategy,
+				ReviewersPerPR:     models.DefaultReviewersPerPR,
+				PairingLookback:    5,
+			},
+		},
+		{
+			name: "переназначение при деактивации",
+			req:  models.CreateTeamRequest{TeamName: "backend", ReassignOnDeactivate: &enabled},
+			want: models.TeamSettings{
+				AssignmentStrategy:   models.DefaultStrategy,
+				ReviewersPerPR:       models.DefaultReviewersPerPR,
+				ReassignOnDeactivate: true,
+			},
+		},
+		{
+			name: "обязательные одобрения перед merge",
+			req:  models.CreateTeamRequest{TeamName: "backend", RequiredApprovals: intPtr(2)},
+			want: models.TeamSettings{
+				AssignmentStrategy: models.DefaultStrategy,
+				ReviewersPerPR:     models.DefaultReviewersPerPR,
+				RequiredApprovals:  2,
+			},
+		},
+		{
+			name:    "отрицательное число одобрений",
+			req:     models.CreateTeamRequest{RequiredApprovals: intPtr(-1)},
+			wantErr: true,
+		},
+		{
+			name:    "pairing_lookback больше максимума",
+			req:     models.CreateTeamRequest{PairingLookback: intPtr(models.MaxPairingLookback + 1)},
+			wantErr: true,
+		},
+		{
+			name:    "команда не может быть партнером самой себе",
+			req:     models.CreateTeamRequest{TeamName: "backend", FallbackTeams: []string{"backend"}},
+			wantErr: true,
+		},
+		{
+			name:    "неизвестная стратегия",
+			req:     models.CreateTeamRequest{AssignmentStrategy: "alphabetical"},
+			wantErr: true,
+		},
+		{
+			name:    "ноль ревьюверов",
+			req:     models.CreateTeamRequest{ReviewersPerPR: intPtr(0)},
+			wantErr: true,
+		},
+		{
+			name:    "больше максимума ревьюверов",
+			req:     models.CreateTeamRequest{ReviewersPerPR: intPtr(models.MaxReviewersPerPR + 1)},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.applyTeamSettings(base, tt.req)
+			if tt.wantErr {
+				if err == nil || !strings.HasPrefix(err.Error(), models.ErrCodeBadRequest) {
+					t.Fatalf("expected %s error, got %v", models.ErrCodeBadRequest, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("applyTeamSettings() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateOutOfOffice(t *testing.T) {
+	start := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		req     models.AddOutOfOfficeRequest
+		wantErr bool
+	}{
+		{
+			name: "корректный период",
+			req:  models.AddOutOfOfficeRequest{UserID: "u1", StartsAt: start, EndsAt: start.Add(14 * 24 * time.Hour), Reason: "vacation"},
+		},
+		{
+			name:    "без пользователя",
+			req:     models.AddOutOfOfficeRequest{StartsAt: start, EndsAt: start.Add(time.Hour)},
+			wantErr: true,
+		},
+		{
+			name:    "без конца периода",
+			req:     models.AddOutOfOfficeRequest{UserID: "u1", StartsAt: start},
+			wantErr: true,
+		},
+		{
+			name:    "конец раньше начала",
+			req:     models.AddOutOfOfficeRequest{UserID: "u1", StartsAt: start, EndsAt: start.Add(-time.Hour)},
+			wantErr: true,
+		},
+		{
+			name:    "пустой период",
+			req:     models.AddOutOfOfficeRequest{UserID: "u1", StartsAt: start, EndsAt: start},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateOutOfOffice(tt.req)
+			if tt.wantErr {
+				if err == nil || !strings.HasPrefix(err.Error(), models.ErrCodeBadRequest) {
+					t.Fatalf("expected %s error, got %v", models.ErrCodeBadRequest, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestRemainingCapacity(t *testing.T) {
+	intPtr := func(v int) *int { return &v }
+
+	if got := remainingCapacity(nil, 5); got != nil {
+		t.Errorf("expected nil for unlimited user, got %d", *got)
+	}
+	if got := remainingCapacity(intPtr(2), 1); got == nil || *got != 1 {
+		t.Errorf("expected 1 remaining review, got %v", got)
+	}
+	// лимит уменьшили ниже текущей нагрузки
+	if got := remainingCapacity(intPtr(2), 3); got == nil || *got != 0 {
+		t.Errorf("expected 0 remaining reviews, got %v", got)
+	}
+}
+
+func TestDeclineRate(t *testing.T) {
+	tests := []struct {
+		declines, reviews int
+		want              float64
+	}{
+		{declines: 0, reviews: 0, want: 0},
+		{declines: 0, reviews: 4, want: 0},
+		{declines: 1, reviews: 3, want: 0.25},
+		{declines: 2, reviews: 0, want: 1},
+	}
+
+	for _, tt := range tests {
+		if got := declineRate(tt.declines, tt.reviews); got != tt.want {
+			t.Errorf("declineRate(%d, %d) = %v, want %v", tt.declines, tt.reviews, got, tt.want)
+		}
+	}
+}
+
+func TestHasErrorCode(t *testing.T) {
+	err := fmt.Errorf("%s: no active replacement candidate in team", models.ErrCodeNoCandidate)
+	if !hasErrorCode(err, models.ErrCodeNoCandidate) {
+		t.Errorf("expected %v to have code %s", err, models.ErrCodeNoCandidate)
+	}
+	if hasErrorCode(err, models.ErrCodeNoCapacity) || hasErrorCode(errors.New("NO_CANDIDATES"), models.ErrCodeNoCandidate) {
+		t.Error("expected only exact code prefix to match")
+	}
+}
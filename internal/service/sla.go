@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"pr-reviewer-service/internal/models"
+	"pr-reviewer-service/internal/repository"
+	"pr-reviewer-service/internal/tenant"
+)
+
+// что нужно сделать с назначением при проверке SLA
+type slaAction int
+
+const (
+	slaNone slaAction = iota
+	slaMarkOverdue
+	slaReassign
+)
+
+// nextSLAAction: ревью, по которому SLA истек, сначала помечается просроченным, а если и после
+// sla_grace_hours рабочих часов ревьювер его не закрыл - переназначается
+func nextSLAAction(a models.ReviewAssignment, reviewer models.User, now time.Time) slaAction {
+	if a.OverdueAt == nil {
+		if workingTimeBetween(reviewer, a.AssignedAt, now) >= time.Duration(a.ReviewSLAHours)*time.Hour {
+			return slaMarkOverdue
+		}
+		return slaNone
+	}
+
+	if workingTimeBetween(reviewer, *a.OverdueAt, now) >= time.Duration(a.SLAGraceHours)*time.Hour {
+		return slaReassign
+	}
+	return slaNone
+}
+
+// RunSLAWorker периодически проверяет SLA ревью, пока не будет отменен ctx
+func (s *Service) RunSLAWorker(ctx context.Context, interval time.Duration) {
+	s.logger.Info("Review SLA worker started, check interval %v", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Review SLA worker stopped")
+			return
+		case <-ticker.C:
+			if err := s.CheckReviewSLA(ctx); err != nil {
+				s.logger.Error("Review SLA check failed: %v", err)
+			}
+		}
+	}
+}
+
+// CheckReviewSLA помечает просроченные ревью и переназначает те, у которых истекло и время ожидания.
+// Проверка выполняется в одной транзакции под advisory lock, поэтому при нескольких экземплярах
+// сервиса одновременно ее выполняет только один, а остальные пропускают свой запуск.
+// Проверяются PR всех организаций
+func (s *Service) CheckReviewSLA(ctx context.Context) error {
+	return s.repo.WithTx(ctx, func(ctx context.Context) error {
+		locked, err := s.repo.TryLockReviewSLA(ctx)
+		if err != nil {
+			return err
+		}
+		if !locked {
+			s.logger.Debug("Review SLA check is already running on another instance, skipping")
+			return nil
+		}
+
+		assignments, err := s.repo.GetReviewAssignmentsPastSLA(ctx)
+		if err != nil {
+			return err
+		}
+		if len(assignments) == 0 {
+			return nil
+		}
+
+		// назначения разных организаций идут подряд, каждая обрабатывается в своем контексте
+		now := time.Now()
+		for start := 0; start < len(assignments); {
+			end := start
+			for end < len(assignments) && assignments[end].OrgID == assignments[start].OrgID {
+				end++
+			}
+			orgCtx := tenant.WithOrg(ctx, assignments[start].OrgID)
+			if err := s.processReviewSLA(orgCtx, assignments[start:end], now); err != nil {
+				return err
+			}
+			start = end
+		}
+
+		return nil
+	})
+}
+
+// processReviewSLA обрабатывает назначения одной организации, организация задана в ctx
+func (s *Service) processReviewSLA(ctx context.Context, assignments []models.ReviewAssignment, now time.Time) error {
+	ids := make([]string, len(assignments))
+	for i, a := range assignments {
+		ids[i] = a.UserID
+	}
+	reviewers, err := s.repo.GetUsers(ctx, uniqueIDs(ids))
+	if err != nil {
+		return err
+	}
+	byID := make(map[string]models.User, len(reviewers))
+	for _, u := range reviewers {
+		byID[u.UserID] = u
+	}
+
+	for _, a := range assignments {
+		reviewer := byID[a.UserID]
+
+		// каждое назначение обрабатывается в своей точке сохранения: ошибка на одном PR
+		// (например, его параллельно изменили через API) не отменяет остальные
+		var err error
+		switch nextSLAAction(a, reviewer, now) {
+		case slaMarkOverdue:
+			err = s.repo.WithTx(ctx, func(ctx context.Context) error {
+				return s.markReviewOverdue(ctx, a)
+			})
+		case slaReassign:
+			err = s.repo.WithTx(ctx, func(ctx context.Context) error {
+				return s.reassignOverdueReview(ctx, a, &reviewer)
+			})
+		}
+		if err != nil {
+			s.logger.Error("Review SLA: failed to process reviewer %s on PR %s: %v", a.UserID, a.PullRequestID, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) markReviewOverdue(ctx context.Context, a models.ReviewAssignment) error {
+	if err := s.repo.MarkReviewOverdue(ctx, a.PullRequestID, a.UserID); err != nil {
+		return err
+	}
+	if err := s.repo.AddSLAEvent(ctx, &models.SLAEvent{
+		PullRequestID: a.PullRequestID,
+		UserID:        a.UserID,
+		Kind:          models.SLAEventOverdue,
+	}); err != nil {
+		return err
+	}
+
+	s.logger.Info("Review SLA: reviewer %s is overdue on PR %s", a.UserID, a.PullRequestID)
+	return nil
+}
+
+// reassignOverdueReview заменяет ревьювера так же, как ReassignReviewer. Если заменить некем,
+// ревьювер остается на PR и замена повторяется при следующей проверке
+func (s *Service) reassignOverdueReview(ctx context.Context, a models.ReviewAssignment, reviewer *models.User) error {
+	pr, err := s.repo.GetPR(ctx, a.PullRequestID)
+	if err != nil {
+		return err
+	}
+
+	newReviewerID, err := s.replaceReviewer(ctx, pr, reviewer, models.AssignmentSLA)
+	if err != nil {
+		if hasErrorCode(err, models.ErrCodeNoCandidate) || hasErrorCode(err, models.ErrCodeNoCapacity) {
+			s.logger.Warn("Review SLA: no replacement for overdue reviewer %s on PR %s: %v", a.UserID, a.PullRequestID, err)
+			return nil
+		}
+		return err
+	}
+
+	if err := s.repo.AddSLAEvent(ctx, &models.SLAEvent{
+		PullRequestID: a.PullRequestID,
+		UserID:        a.UserID,
+		Kind:          models.SLAEventReassigned,
+		ReplacedBy:    newReviewerID,
+	}); err != nil {
+		return err
+	}
+
+	s.logger.Info("Review SLA: overdue reviewer %s on PR %s replaced by %s", a.UserID, a.PullRequestID, newReviewerID)
+	return nil
+}
+
+// события SLA ревью PR
+func (s *Service) GetSLAEvents(ctx context.Context, prID string) ([]models.SLAEvent, error) {
+	if _, err := s.repo.GetPR(ctx, prID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("%s: PR not found", models.ErrCodeNotFound)
+		}
+		return nil, err
+	}
+	return s.repo.GetSLAEvents(ctx, prID)
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"pr-reviewer-service/internal/models"
+)
+
+func TestNextSLAAction(t *testing.T) {
+	office := &models.WorkingHours{Start: "09:00", End: "18:00", Days: []int{1, 2, 3, 4, 5}}
+	reviewer := models.User{UserID: "u2", Timezone: "UTC", WorkingHours: office}
+	assignedAt := time.Date(2025, 7, 1, 9, 0, 0, 0, time.UTC) // вторник
+	overdueAt := time.Date(2025, 7, 4, 9, 0, 0, 0, time.UTC)  // пятница, 27 рабочих часов спустя
+
+	tests := []struct {
+		name       string
+		assignment models.ReviewAssignment
+		now        time.Time
+		want       slaAction
+	}{
+		{
+			name:       "SLA еще не истек",
+			assignment: models.ReviewAssignment{AssignedAt: assignedAt, ReviewSLAHours: 24, SLAGraceHours: 8},
+			now:        time.Date(2025, 7, 3, 12, 0, 0, 0, time.UTC), // 21 рабочий час
+			want:       slaNone,
+		},
+		{
+			name:       "SLA истек - пометить просроченным",
+			assignment: models.ReviewAssignment{AssignedAt: assignedAt, ReviewSLAHours: 24, SLAGraceHours: 8},
+			now:        time.Date(2025, 7, 3, 15, 0, 0, 0, time.UTC), // 24 рабочих часа
+			want:       slaMarkOverdue,
+		},
+		{
+			name: "ожидание после просрочки истекло - переназначить",
+			assignment: models.ReviewAssignment{
+				AssignedAt: assignedAt, OverdueAt: &overdueAt, ReviewSLAHours: 24, SLAGraceHours: 8,
+			},
+			now:  time.Date(2025, 7, 5, 12, 0, 0, 0, time.UTC), // суббота, за пятницу прошло 9 рабочих часов
+			want: slaReassign,
+		},
+		{
+			name: "просрочено, ожидание еще идет",
+			assignment: models.ReviewAssignment{
+				AssignedAt: assignedAt, OverdueAt: &overdueAt, ReviewSLAHours: 24, SLAGraceHours: 16,
+			},
+			now:  time.Date(2025, 7, 7, 12, 0, 0, 0, time.UTC), // понедельник, 9 + 3 рабочих часа
+			want: slaNone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextSLAAction(tt.assignment, reviewer, tt.now); got != tt.want {
+				t.Errorf("nextSLAAction() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
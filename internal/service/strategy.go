@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"math/rand"
+
+	"pr-reviewer-service/internal/models"
+	"pr-reviewer-service/internal/repository"
+)
+
+// ReviewerStrategy выбирает ревьюверов из уже отфильтрованных групп кандидатов
+// (автор, неактивные и текущие ревьюверы исключаются до вызова стратегии)
+type ReviewerStrategy interface {
+	Name() string
+	Select(ctx context.Context, req SelectionRequest) ([]models.User, error)
+}
+
+// SelectionRequest - входные данные для стратегии
+type SelectionRequest struct {
+	TeamName string
+	// кандидаты группами по убыванию приоритета: следующая группа рассматривается,
+	// только если в предыдущих не хватило кандидатов
+	Groups [][]models.User
+	// количество открытых ревью у кандидатов, кандидатов без ревью в карте нет
+	Load  map[string]int
+	Count int
+	// источник случайности выбора, его seed сохраняется в объяснении назначения
+	Rand *rand.Rand
+	// пробный выбор: стратегия не должна менять сохраненное состояние
+	DryRun bool
+	// состояние круга round_robin, загруженное вызывающим: стратегия меняет его в памяти
+	// и не обращается к базе, сохраняет его вызывающий. nil - состояние хранится в базе
+	Rotation map[string]bool
+}
+
+func defaultStrategies(repo *repository.Repository) map[string]ReviewerStrategy {
+	strategies := []ReviewerStrategy{
+		randomStrategy{},
+		leastLoadedStrategy{},
+		roundRobinStrategy{repo: repo},
+		weightedStrategy{},
+	}
+
+	registry := make(map[string]ReviewerStrategy, len(strategies))
+	for _, strategy := range strategies {
+		registry[strategy.Name()] = strategy
+	}
+	return registry
+}
+
+// случайный выбор без учета нагрузки
+type randomStrategy struct{}
+
+func (randomStrategy) Name() string { return models.StrategyRandom }
+
+func (randomStrategy) Select(_ context.Context, req SelectionRequest) ([]models.User, error) {
+	return selectFromGroups(req.Groups, req.Count, func(group []models.User, n int) []models.User {
+		return selectRandomReviewers(req.Rand, group, n)
+	}), nil
+}
+
+// кандидаты с наименьшим числом открытых ревью, при равенстве - случайно
+type leastLoadedStrategy struct{}
+
+func (leastLoadedStrategy) Name() string { return models.StrategyLeastLoaded }
+
+func (leastLoadedStrategy) Select(_ context.Context, req SelectionRequest) ([]models.User, error) {
+	return selectFromGroups(req.Groups, req.Count, func(group []models.User, n int) []models.User {
+		return selectLeastLoadedReviewers(req.Rand, group, req.Load, n)
+	}), nil
+}
+
+// selectFromGroups выбирает до count кандидатов функцией pick, переходя к следующей группе,
+// только если в предыдущих кандидатов не хватило
+func selectFromGroups(groups [][]models.User, count int, pick func(group []models.User, n int) []models.User) []models.User {
+	selected := []models.User{}
+	for _, group := range groups {
+		if len(selected) >= count {
+			break
+		}
+		if len(group) > 0 {
+			selected = append(selected, pick(group, count-len(selected))...)
+		}
+	}
+	return selected
+}
+
+// по очереди внутри команды: каждый активный участник выбирается один раз,
+// прежде чем кто-то будет выбран повторно. Решение принимается один раз по всем группам кандидатов:
+// не выбранные в текущем круге берутся раньше выбранных независимо от группы, а группы задают порядок
+// среди них. Состояние круга хранится в Postgres, поэтому очередь общая для всех экземпляров сервиса
+type roundRobinStrategy struct {
+	repo *repository.Repository
+}
+
+func (roundRobinStrategy) Name() string { return models.StrategyRoundRobin }
+
+func (s roundRobinStrategy) Select(ctx context.Context, req SelectionRequest) ([]models.User, error) {
+	if req.Rotation != nil {
+		return advanceRotation(req.Rand, req.Groups, req.Rotation, req.Count), nil
+	}
+
+	// при пробном выборе очередь только читается, без блокировки и без сдвига
+	if req.DryRun {
+		picked, err := s.repo.GetRotationPicks(ctx, req.TeamName)
+		if err != nil {
+			return nil, err
+		}
+		current, next := pickFromBag(req.Rand, req.Groups, picked, req.Count)
+		return append(current, next...), nil
+	}
+
+	var selected []models.User
+
+	err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+		// параллельные выборы для одной команды выполняются строго по очереди
+		if err := s.repo.LockTeamRotation(ctx, req.TeamName); err != nil {
+			return err
+		}
+
+		picked, err := s.repo.GetRotationPicks(ctx, req.TeamName)
+		if err != nil {
+			return err
+		}
+
+		current, next := pickFromBag(req.Rand, req.Groups, picked, req.Count)
+		selected = append(current, next...)
+
+		if len(next) == 0 {
+			return s.repo.AddRotationPicks(ctx, req.TeamName, userIDs(current))
+		}
+
+		// круг кандидатов закончился: в новом круге из них уже выбраны только next. Отметки участников,
+		// которые сейчас не кандидаты (автор, уже назначенные), сохраняются - иначе узкий пул кандидатов
+		// (например, только владельцы файлов) обнулял бы очередь всей команды
+		var candidates []models.User
+		for _, group := range req.Groups {
+			candidates = append(candidates, group...)
+		}
+		if err := s.repo.ResetRotation(ctx, req.TeamName, userIDs(candidates)); err != nil {
+			return err
+		}
+		return s.repo.AddRotationPicks(ctx, req.TeamName, userIDs(next))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return selected, nil
+}
+
+// advanceRotation выбирает кандидатов по кругу, хранящемуся в памяти, и сдвигает его так же,
+// как Select сдвигает круг в базе
+func advanceRotation(rng *rand.Rand, groups [][]models.User, picked map[string]bool, maxCount int) []models.User {
+	current, next := pickFromBag(rng, groups, picked, maxCount)
+	selected := append(current, next...)
+
+	marked := current
+	if len(next) > 0 {
+		// новый круг для кандидатов этого выбора, в нем уже выбраны только next
+		for _, group := range groups {
+			for _, c := range group {
+				delete(picked, c.UserID)
+			}
+		}
+		marked = next
+	}
+	for _, c := range marked {
+		picked[c.UserID] = true
+	}
+	return selected
+}
+
+// pickFromBag выбирает до maxCount кандидатов. Сначала берутся те, кто еще не был выбран
+// в текущем круге (current): по порядку групп, внутри группы - в случайном порядке. Только если
+// таких нет ни в одной группе, начинается новый круг и недостающие добираются из остальных
+// кандидатов, тоже по порядку групп (next)
+func pickFromBag(rng *rand.Rand, groups [][]models.User, picked map[string]bool, maxCount int) (current, next []models.User) {
+	var rest []models.User
+	total := 0
+	for _, group := range groups {
+		total += len(group)
+		for _, i := range shuffledOrder(rng, len(group)) {
+			c := group[i]
+			switch {
+			case !picked[c.UserID] && len(current) < maxCount:
+				current = append(current, c)
+			case len(rest) < maxCount:
+				// для нового круга нужно не больше maxCount первых из остальных
+				rest = append(rest, c)
+			}
+		}
+	}
+
+	// автор и неактивные участники не попадают в кандидаты, поэтому круг может закончиться
+	// и тогда, когда невыбранными остались только они
+	need := min(maxCount, total) - len(current)
+	if need > 0 {
+		next = rest[:need]
+	}
+
+	return current, next
+}
+
+func userIDs(users []models.User) []string {
+	ids := make([]string, len(users))
+	for i, u := range users {
+		ids[i] = u.UserID
+	}
+	return ids
+}
+
+// случайный выбор с весом 1/(открытые ревью + 1):
+// загруженные участники выбираются реже, но не исключаются полностью
+type weightedStrategy struct{}
+
+func (weightedStrategy) Name() string { return models.StrategyWeighted }
+
+func (weightedStrategy) Select(_ context.Context, req SelectionRequest) ([]models.User, error) {
+	return selectFromGroups(req.Groups, req.Count, func(group []models.User, n int) []models.User {
+		return selectWeightedReviewers(req.Rand, group, req.Load, n)
+	}), nil
+}
+
+func selectWeightedReviewers(rng *rand.Rand, candidates []models.User, load map[string]int, maxCount int) []models.User {
+	pool := make([]models.User, len(candidates))
+	copy(pool, candidates)
+
+	count := min(maxCount, len(pool))
+	selected := make([]models.User, 0, count)
+	for len(selected) < count {
+		total := 0.0
+		for _, c := range pool {
+			total += candidateWeight(load[c.UserID])
+		}
+
+		// выбираем кандидата пропорционально весу и убираем его из пула
+		point := rng.Float64() * total
+		idx := len(pool) - 1
+		for i, c := range pool {
+			point -= candidateWeight(load[c.UserID])
+			if point < 0 {
+				idx = i
+				break
+			}
+		}
+
+		selected = append(selected, pool[idx])
+		pool = append(pool[:idx], pool[idx+1:]...)
+	}
+	return selected
+}
+
+func candidateWeight(openReviews int) float64 {
+	return 1 / float64(openReviews+1)
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"pr-reviewer-service/internal/models"
+)
+
+func TestDefaultStrategies(t *testing.T) {
+	registry := defaultStrategies(nil)
+
+	for _, name := range []string{
+		models.StrategyRandom,
+		models.StrategyLeastLoaded,
+		models.StrategyRoundRobin,
+		models.StrategyWeighted,
+	} {
+		strategy, ok := registry[name]
+		if !ok {
+			t.Errorf("strategy %s is not registered", name)
+			continue
+		}
+		if strategy.Name() != name {
+			t.Errorf("strategy registered as %s reports name %s", name, strategy.Name())
+		}
+	}
+}
+
+func TestPickFromBag(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	candidates := []models.User{
+		{UserID: "u1", Username: "User1", TeamName: "backend", IsActive: true},
+		{UserID: "u2", Username: "User2", TeamName: "backend", IsActive: true},
+		{UserID: "u3", Username: "User3", TeamName: "backend", IsActive: true},
+		{UserID: "u4", Username: "User4", TeamName: "backend", IsActive: true},
+	}
+
+	tests := []struct {
+		name        string
+		picked      map[string]bool
+		maxCount    int
+		wantCurrent []string
+		wantNextLen int
+	}{
+		{
+			name:        "новый круг без выбранных",
+			picked:      map[string]bool{},
+			maxCount:    4,
+			wantCurrent: []string{"u1", "u2", "u3", "u4"},
+		},
+		{
+			name:        "берутся только не выбранные в круге",
+			picked:      map[string]bool{"u1": true, "u3": true},
+			maxCount:    2,
+			wantCurrent: []string{"u2", "u4"},
+		},
+		{
+			name:        "не выбранных не хватает - начинается новый круг",
+			picked:      map[string]bool{"u1": true, "u2": true, "u3": true},
+			maxCount:    2,
+			wantCurrent: []string{"u4"},
+			wantNextLen: 1,
+		},
+		{
+			name:        "в мешке только не кандидаты (автор)",
+			picked:      map[string]bool{"u1": true, "u2": true, "u3": true, "u4": true, "author": true},
+			maxCount:    1,
+			wantNextLen: 1,
+		},
+		{
+			name:        "кандидатов меньше чем maxCount",
+			picked:      map[string]bool{"u1": true},
+			maxCount:    10,
+			wantCurrent: []string{"u2", "u3", "u4"},
+			wantNextLen: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current, next := pickFromBag(rng, [][]models.User{candidates}, tt.picked, tt.maxCount)
+
+			if len(current) != len(tt.wantCurrent) {
+				t.Fatalf("pickFromBag() current has %d users, want %d", len(current), len(tt.wantCurrent))
+			}
+			want := make(map[string]bool)
+			for _, id := range tt.wantCurrent {
+				want[id] = true
+			}
+			for _, u := range current {
+				if !want[u.UserID] {
+					t.Errorf("pickFromBag() unexpected user %s in current round", u.UserID)
+				}
+			}
+
+			if len(next) != tt.wantNextLen {
+				t.Fatalf("pickFromBag() next has %d users, want %d", len(next), tt.wantNextLen)
+			}
+
+			// никто не выбирается дважды за один вызов
+			seen := make(map[string]bool)
+			for _, u := range append(current, next...) {
+				if seen[u.UserID] {
+					t.Errorf("pickFromBag() returned duplicate user %s", u.UserID)
+				}
+				seen[u.UserID] = true
+			}
+		})
+	}
+}
+
+func TestPickFromBag_FairTurns(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	// симулируем последовательные выборы по одному, как это делает roundRobinStrategy
+	candidates := []models.User{
+		{UserID: "u1", Username: "User1", TeamName: "backend", IsActive: true},
+		{UserID: "u2", Username: "User2", TeamName: "backend", IsActive: true},
+		{UserID: "u3", Username: "User3", TeamName: "backend", IsActive: true},
+	}
+	picked := map[string]bool{}
+	counts := make(map[string]int)
+
+	for i := 0; i < len(candidates)*3; i++ {
+		current, next := pickFromBag(rng, [][]models.User{candidates}, picked, 1)
+		if len(next) > 0 {
+			picked = map[string]bool{}
+			current = next
+		}
+		for _, u := range current {
+			picked[u.UserID] = true
+			counts[u.UserID]++
+		}
+
+		// после каждого полного круга все выбраны одинаковое число раз
+		if (i+1)%len(candidates) == 0 {
+			for _, c := range candidates {
+				if counts[c.UserID] != (i+1)/len(candidates) {
+					t.Fatalf("after %d picks user %s picked %d times", i+1, c.UserID, counts[c.UserID])
+				}
+			}
+		}
+	}
+}
+
+func TestPickFromBag_Groups(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	// приоритетная группа (например, по тегам) уже выбрана в текущем круге
+	groups := [][]models.User{
+		{{UserID: "u1", IsActive: true}, {UserID: "u2", IsActive: true}},
+		{{UserID: "u3", IsActive: true}, {UserID: "u4", IsActive: true}},
+	}
+
+	t.Run("круг не заканчивается, пока в других группах есть не выбранные", func(t *testing.T) {
+		current, next := pickFromBag(rng, groups, map[string]bool{"u1": true, "u2": true}, 1)
+		if len(next) != 0 || len(current) != 1 || (current[0].UserID != "u3" && current[0].UserID != "u4") {
+			t.Errorf("expected u3 or u4 from the current round, got current %v next %v", current, next)
+		}
+	})
+
+	t.Run("не выбранные берутся по порядку групп", func(t *testing.T) {
+		current, _ := pickFromBag(rng, groups, map[string]bool{"u1": true, "u3": true}, 1)
+		if len(current) != 1 || current[0].UserID != "u2" {
+			t.Errorf("expected u2 from the first group, got %v", current)
+		}
+	})
+
+	t.Run("новый круг начинается с приоритетной группы", func(t *testing.T) {
+		picked := map[string]bool{"u1": true, "u2": true, "u3": true, "u4": true}
+		current, next := pickFromBag(rng, groups, picked, 1)
+		if len(current) != 0 || len(next) != 1 || (next[0].UserID != "u1" && next[0].UserID != "u2") {
+			t.Errorf("expected u1 or u2 to start the new round, got current %v next %v", current, next)
+		}
+	})
+}
+
+func TestSelectFromGroups(t *testing.T) {
+	groups := [][]models.User{
+		{{UserID: "u1"}},
+		{},
+		{{UserID: "u2"}, {UserID: "u3"}},
+	}
+	first := func(group []models.User, n int) []models.User { return group[:min(n, len(group))] }
+
+	got := userIDs(selectFromGroups(groups, 2, first))
+	if want := []string{"u1", "u2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("selectFromGroups() = %v, want %v", got, want)
+	}
+}
+
+func TestSelectWeightedReviewers(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	candidates := []models.User{
+		{UserID: "u1", Username: "User1", TeamName: "backend", IsActive: true},
+		{UserID: "u2", Username: "User2", TeamName: "backend", IsActive: true},
+	}
+	load := map[string]int{"u1": 9}
+
+	picked := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		result := selectWeightedReviewers(rng, candidates, load, 1)
+		if len(result) != 1 {
+			t.Fatalf("expected 1 reviewer, got %d", len(result))
+		}
+		picked[result[0].UserID]++
+	}
+
+	// вес u2 в 10 раз больше, поэтому он должен выбираться заметно чаще
+	if picked["u2"] <= picked["u1"] {
+		t.Errorf("expected less loaded u2 to be picked more often, got %v", picked)
+	}
+
+	// без дубликатов при выборе всех кандидатов
+	result := selectWeightedReviewers(rng, candidates, load, 5)
+	if len(result) != 2 || result[0].UserID == result[1].UserID {
+		t.Errorf("expected both candidates exactly once, got %v", result)
+	}
+}
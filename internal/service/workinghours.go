@@ -0,0 +1,227 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	// база часовых поясов встроена в бинарник: в образе alpine ее нет
+	_ "time/tzdata"
+
+	"pr-reviewer-service/internal/models"
+)
+
+const clockLayout = "15:04"
+
+// рабочие дни по умолчанию - понедельник-пятница
+var defaultWorkingDays = []int{1, 2, 3, 4, 5}
+
+// normalizeSchedule проверяет часовой пояс и рабочее время участника,
+// дни недели сортируются без повторов, пустой список заменяется на пн-пт
+func normalizeSchedule(member *models.TeamMember) error {
+	member.Timezone = strings.TrimSpace(member.Timezone)
+	if member.Timezone != "" {
+		if _, err := time.LoadLocation(member.Timezone); err != nil {
+			return fmt.Errorf("%s: unknown timezone %q for user %s", models.ErrCodeBadRequest, member.Timezone, member.UserID)
+		}
+	}
+
+	wh := member.WorkingHours
+	if wh == nil {
+		return nil
+	}
+
+	start, errStart := time.Parse(clockLayout, wh.Start)
+	end, errEnd := time.Parse(clockLayout, wh.End)
+	if errStart != nil || errEnd != nil || start.Equal(end) {
+		return fmt.Errorf("%s: invalid working_hours %s-%s for user %s, expected HH:MM",
+			models.ErrCodeBadRequest, wh.Start, wh.End, member.UserID)
+	}
+	wh.Start, wh.End = start.Format(clockLayout), end.Format(clockLayout)
+
+	if len(wh.Days) == 0 {
+		wh.Days = append([]int(nil), defaultWorkingDays...)
+		return nil
+	}
+	seen := make(map[int]bool, len(wh.Days))
+	days := []int{}
+	for _, d := range wh.Days {
+		if d < 1 || d > 7 {
+			return fmt.Errorf("%s: invalid working day %d for user %s, expected 1..7", models.ErrCodeBadRequest, d, member.UserID)
+		}
+		if !seen[d] {
+			seen[d] = true
+			days = append(days, d)
+		}
+	}
+	sort.Ints(days)
+	wh.Days = days
+
+	return nil
+}
+
+// загруженные часовые пояса: LoadLocation каждый раз читает встроенную базу заново
+var locations sync.Map
+
+// loadLocation возвращает часовой пояс по имени IANA, неизвестный или пустой - UTC
+func loadLocation(name string) *time.Location {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		loc = time.UTC
+	}
+	locations.Store(name, loc)
+	return loc
+}
+
+// schedule - разобранное расписание пользователя, чтобы не разбирать его при каждом расчете окна
+type schedule struct {
+	start, end time.Time
+	days       [8]bool
+	loc        *time.Location
+}
+
+// parseSchedule разбирает расписание пользователя, без расписания или с некорректным временем возвращает nil
+func parseSchedule(u models.User) *schedule {
+	wh := u.WorkingHours
+	if wh == nil {
+		return nil
+	}
+
+	start, errStart := time.Parse(clockLayout, wh.Start)
+	end, errEnd := time.Parse(clockLayout, wh.End)
+	if errStart != nil || errEnd != nil {
+		return nil
+	}
+
+	sc := &schedule{start: start, end: end, loc: loadLocation(u.Timezone)}
+	days := wh.Days
+	if len(days) == 0 {
+		days = defaultWorkingDays
+	}
+	for _, d := range days {
+		if d >= 1 && d <= 7 {
+			sc.days[d] = true
+		}
+	}
+	return sc
+}
+
+// nextWorkingWindow возвращает рабочее окно пользователя, которое идет сейчас или начнется раньше других.
+// Время окна - в часовом поясе пользователя. Без расписания возвращает nil
+func nextWorkingWindow(u models.User, now time.Time) *models.WorkingWindow {
+	return parseSchedule(u).nextWindow(now)
+}
+
+func (sc *schedule) nextWindow(now time.Time) *models.WorkingWindow {
+	if sc == nil {
+		return nil
+	}
+
+	loc := sc.loc
+	local := now.In(loc)
+	// со вчерашнего дня, чтобы учесть ночную смену, которая еще не закончилась
+	for offset := -1; offset <= 7; offset++ {
+		day := time.Date(local.Year(), local.Month(), local.Day()+offset, 0, 0, 0, 0, loc)
+		if !sc.days[isoWeekday(day)] {
+			continue
+		}
+
+		windowStart := time.Date(day.Year(), day.Month(), day.Day(), sc.start.Hour(), sc.start.Minute(), 0, 0, loc)
+		windowEnd := time.Date(day.Year(), day.Month(), day.Day(), sc.end.Hour(), sc.end.Minute(), 0, 0, loc)
+		if !windowEnd.After(windowStart) {
+			windowEnd = windowEnd.AddDate(0, 0, 1)
+		}
+		if windowEnd.After(now) {
+			return &models.WorkingWindow{Start: windowStart, End: windowEnd}
+		}
+	}
+
+	return nil
+}
+
+// 1 - понедельник ... 7 - воскресенье
+func isoWeekday(t time.Time) int {
+	if t.Weekday() == time.Sunday {
+		return 7
+	}
+	return int(t.Weekday())
+}
+
+// workingAt - пользователь в рабочем времени по окну из nextWorkingWindow (без расписания - всегда)
+func workingAt(u models.User, window *models.WorkingWindow, now time.Time) bool {
+	if u.WorkingHours == nil {
+		return true
+	}
+	return window != nil && !window.Start.After(now)
+}
+
+// preferAvailable делит каждую группу на тех, кто сейчас в рабочем времени, тех, у кого рабочее время
+// начнется раньше остальных, и всех остальных. Порядок исходных групп сохраняется
+func preferAvailable(groups [][]models.User, now time.Time) [][]models.User {
+	result := make([][]models.User, 0, len(groups)*3)
+	for _, group := range groups {
+		var working, soonest, rest []models.User
+		var soonestStart time.Time
+
+		for _, c := range group {
+			window := nextWorkingWindow(c, now)
+			if workingAt(c, window, now) {
+				working = append(working, c)
+				continue
+			}
+
+			switch {
+			case window == nil:
+				rest = append(rest, c)
+			case soonest == nil || window.Start.Before(soonestStart):
+				rest = append(rest, soonest...)
+				soonest = []models.User{c}
+				soonestStart = window.Start
+			case window.Start.Equal(soonestStart):
+				soonest = append(soonest, c)
+			default:
+				rest = append(rest, c)
+			}
+		}
+
+		result = append(result, working, soonest, rest)
+	}
+	return result
+}
+
+// workingTimeBetween - сколько рабочего времени пользователя прошло между from и to.
+// Без расписания пользователь считается работающим всегда
+func workingTimeBetween(u models.User, from, to time.Time) time.Duration {
+	if !to.After(from) {
+		return 0
+	}
+	if u.WorkingHours == nil {
+		return to.Sub(from)
+	}
+
+	sc := parseSchedule(u)
+	var total time.Duration
+	for t := from; t.Before(to); {
+		window := sc.nextWindow(t)
+		if window == nil || !window.Start.Before(to) {
+			break
+		}
+
+		start, end := window.Start, window.End
+		if start.Before(t) {
+			start = t
+		}
+		if end.After(to) {
+			end = to
+		}
+		total += end.Sub(start)
+		t = window.End
+	}
+
+	return total
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"pr-reviewer-service/internal/models"
+)
+
+func TestNextWorkingWindow(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatalf("failed to load timezone: %v", err)
+	}
+	office := &models.WorkingHours{Start: "09:00", End: "18:00", Days: []int{1, 2, 3, 4, 5}}
+	night := &models.WorkingHours{Start: "22:00", End: "06:00", Days: []int{1, 2, 3, 4, 5}}
+
+	tests := []struct {
+		name      string
+		user      models.User
+		now       time.Time
+		wantStart time.Time
+		wantEnd   time.Time
+		wantNil   bool
+	}{
+		{
+			name:    "без расписания",
+			user:    models.User{UserID: "u1", Timezone: "Europe/Moscow"},
+			now:     time.Date(2025, 7, 2, 12, 0, 0, 0, moscow),
+			wantNil: true,
+		},
+		{
+			name:      "в рабочее время - текущее окно",
+			user:      models.User{UserID: "u1", Timezone: "Europe/Moscow", WorkingHours: office},
+			now:       time.Date(2025, 7, 2, 10, 30, 0, 0, moscow), // среда
+			wantStart: time.Date(2025, 7, 2, 9, 0, 0, 0, moscow),
+			wantEnd:   time.Date(2025, 7, 2, 18, 0, 0, 0, moscow),
+		},
+		{
+			name:      "вечером - окно следующего дня",
+			user:      models.User{UserID: "u1", Timezone: "Europe/Moscow", WorkingHours: office},
+			now:       time.Date(2025, 7, 2, 19, 0, 0, 0, moscow),
+			wantStart: time.Date(2025, 7, 3, 9, 0, 0, 0, moscow),
+			wantEnd:   time.Date(2025, 7, 3, 18, 0, 0, 0, moscow),
+		},
+		{
+			name:      "в выходные - окно понедельника",
+			user:      models.User{UserID: "u1", Timezone: "Europe/Moscow", WorkingHours: office},
+			now:       time.Date(2025, 7, 5, 12, 0, 0, 0, moscow), // суббота
+			wantStart: time.Date(2025, 7, 7, 9, 0, 0, 0, moscow),
+			wantEnd:   time.Date(2025, 7, 7, 18, 0, 0, 0, moscow),
+		},
+		{
+			name:      "ночная смена, начатая вчера",
+			user:      models.User{UserID: "u1", Timezone: "Europe/Moscow", WorkingHours: night},
+			now:       time.Date(2025, 7, 2, 3, 0, 0, 0, moscow),
+			wantStart: time.Date(2025, 7, 1, 22, 0, 0, 0, moscow),
+			wantEnd:   time.Date(2025, 7, 2, 6, 0, 0, 0, moscow),
+		},
+		{
+			name:      "часовой пояс пользователя, а не сервера",
+			user:      models.User{UserID: "u1", Timezone: "Asia/Almaty", WorkingHours: office},
+			now:       time.Date(2025, 7, 2, 4, 0, 0, 0, time.UTC), // 09:00 в Алматы
+			wantStart: time.Date(2025, 7, 2, 4, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2025, 7, 2, 13, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := nextWorkingWindow(tt.user, tt.now)
+			if tt.wantNil {
+				if got != nil {
+					t.Fatalf("nextWorkingWindow() = %+v, want nil", got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatalf("nextWorkingWindow() = nil, want %v - %v", tt.wantStart, tt.wantEnd)
+			}
+			if !got.Start.Equal(tt.wantStart) || !got.End.Equal(tt.wantEnd) {
+				t.Errorf("nextWorkingWindow() = %v - %v, want %v - %v", got.Start, got.End, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestPreferAvailable(t *testing.T) {
+	office := &models.WorkingHours{Start: "09:00", End: "18:00", Days: []int{1, 2, 3, 4, 5}}
+	// 08:00 UTC: в Москве 11:00, в Берлине 10:00, в Алматы 13:00, в Нью-Йорке 04:00
+	now := time.Date(2025, 7, 2, 8, 0, 0, 0, time.UTC)
+
+	groups := [][]models.User{{
+		{UserID: "msk", Timezone: "Europe/Moscow", WorkingHours: office},
+		{UserID: "nyc", Timezone: "America/New_York", WorkingHours: office},
+		{UserID: "any", Timezone: "UTC"},
+		{UserID: "la", Timezone: "America/Los_Angeles", WorkingHours: office},
+	}}
+
+	got := preferAvailable(groups, now)
+	want := [][]string{{"msk", "any"}, {"nyc"}, {"la"}}
+
+	if len(got) != len(want) {
+		t.Fatalf("expected %d groups, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(userIDs(got[i]), want[i]) {
+			t.Errorf("group %d = %v, want %v", i, userIDs(got[i]), want[i])
+		}
+	}
+}
+
+func TestNormalizeSchedule(t *testing.T) {
+	member := models.TeamMember{
+		UserID:       "u1",
+		Timezone:     " Europe/Berlin ",
+		WorkingHours: &models.WorkingHours{Start: "9:00", End: "17:30", Days: []int{5, 1, 1}},
+	}
+	if err := normalizeSchedule(&member); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := models.WorkingHours{Start: "09:00", End: "17:30", Days: []int{1, 5}}
+	if member.Timezone != "Europe/Berlin" || !reflect.DeepEqual(*member.WorkingHours, want) {
+		t.Errorf("unexpected schedule after normalization: %q %+v", member.Timezone, *member.WorkingHours)
+	}
+
+	defaults := models.TeamMember{UserID: "u2", WorkingHours: &models.WorkingHours{Start: "10:00", End: "19:00"}}
+	if err := normalizeSchedule(&defaults); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(defaults.WorkingHours.Days, defaultWorkingDays) {
+		t.Errorf("expected default working days, got %v", defaults.WorkingHours.Days)
+	}
+
+	for _, invalid := range []models.TeamMember{
+		{UserID: "u3", Timezone: "Mars/Olympus"},
+		{UserID: "u4", WorkingHours: &models.WorkingHours{Start: "9am", End: "18:00"}},
+		{UserID: "u5", WorkingHours: &models.WorkingHours{Start: "09:00", End: "09:00"}},
+		{UserID: "u6", WorkingHours: &models.WorkingHours{Start: "09:00", End: "18:00", Days: []int{0}}},
+	} {
+		err := normalizeSchedule(&invalid)
+		if err == nil || !strings.HasPrefix(err.Error(), models.ErrCodeBadRequest) {
+			t.Errorf("expected %s error for %s, got %v", models.ErrCodeBadRequest, invalid.UserID, err)
+		}
+	}
+}
+
+func TestWorkingTimeBetween(t *testing.T) {
+	office := &models.WorkingHours{Start: "09:00", End: "18:00", Days: []int{1, 2, 3, 4, 5}}
+	user := models.User{UserID: "u1", Timezone: "UTC", WorkingHours: office}
+
+	tests := []struct {
+		name     string
+		user     models.User
+		from, to time.Time
+		want     time.Duration
+	}{
+		{
+			name: "без расписания - календарное время",
+			user: models.User{UserID: "u1"},
+			from: time.Date(2025, 7, 4, 20, 0, 0, 0, time.UTC),
+			to:   time.Date(2025, 7, 5, 8, 0, 0, 0, time.UTC),
+			want: 12 * time.Hour,
+		},
+		{
+			name: "внутри одного рабочего дня",
+			user: user,
+			from: time.Date(2025, 7, 2, 10, 0, 0, 0, time.UTC), // среда
+			to:   time.Date(2025, 7, 2, 12, 30, 0, 0, time.UTC),
+			want: 150 * time.Minute,
+		},
+		{
+			name: "через выходные",
+			user: user,
+			from: time.Date(2025, 7, 4, 17, 0, 0, 0, time.UTC), // пятница
+			to:   time.Date(2025, 7, 7, 10, 0, 0, 0, time.UTC), // понедельник
+			want: 2 * time.Hour,
+		},
+		{
+			name: "назначение вне рабочего времени",
+			user: user,
+			from: time.Date(2025, 7, 1, 20, 0, 0, 0, time.UTC), // вторник вечером
+			to:   time.Date(2025, 7, 3, 18, 0, 0, 0, time.UTC),
+			want: 18 * time.Hour,
+		},
+		{
+			name: "конец раньше начала",
+			user: user,
+			from: time.Date(2025, 7, 2, 12, 0, 0, 0, time.UTC),
+			to:   time.Date(2025, 7, 2, 10, 0, 0, 0, time.UTC),
+			want: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := workingTimeBetween(tt.user, tt.from, tt.to); got != tt.want {
+				t.Errorf("workingTimeBetween() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadLocation_Cached(t *testing.T) {
+	first := loadLocation("Asia/Tokyo")
+	if first.String() != "Asia/Tokyo" {
+		t.Fatalf("loadLocation() = %v, want Asia/Tokyo", first)
+	}
+	if second := loadLocation("Asia/Tokyo"); second != first {
+		t.Error("loadLocation() loaded the same timezone twice")
+	}
+	if got := loadLocation("Mars/Olympus"); got != time.UTC {
+		t.Errorf("loadLocation() for unknown timezone = %v, want UTC", got)
+	}
+}
@@ -0,0 +1,21 @@
+package tenant
+
+import "context"
+
+// DefaultOrg - организация запросов без API-токена и данных, созданных до появления организаций
+const DefaultOrg = "default"
+
+type orgKey struct{}
+
+// WithOrg возвращает контекст, в котором все запросы к репозиторию ограничены организацией orgID
+func WithOrg(ctx context.Context, orgID string) context.Context {
+	return context.WithValue(ctx, orgKey{}, orgID)
+}
+
+// OrgFromContext возвращает организацию из контекста, DefaultOrg - если она не задана
+func OrgFromContext(ctx context.Context) string {
+	if orgID, ok := ctx.Value(orgKey{}).(string); ok && orgID != "" {
+		return orgID
+	}
+	return DefaultOrg
+}
@@ -0,0 +1,4 @@
+-- стратегия выбора ревьюверов, настраивается для каждой команды
+ALTER TABLE teams
+    ADD COLUMN assignment_strategy VARCHAR(32) NOT NULL DEFAULT 'least_loaded'
+    CHECK (assignment_strategy IN ('random', 'least_loaded', 'round_robin', 'weighted'));
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS pull_request_reviewers_limit ON pull_request_reviewers;
+DROP FUNCTION IF EXISTS check_pull_request_reviewers_limit();
+ALTER TABLE teams DROP COLUMN IF EXISTS reviewers_per_pr;
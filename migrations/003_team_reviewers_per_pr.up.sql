@@ -0,0 +1,18 @@
+-- сколько ревьюверов назначается на новый PR команды (раньше всегда до 2)
+ALTER TABLE teams
+    ADD COLUMN reviewers_per_pr SMALLINT NOT NULL DEFAULT 2
+    CHECK (reviewers_per_pr BETWEEN 1 AND 10);
+
+-- на одном PR не может быть больше 10 ревьюверов (верхняя граница reviewers_per_pr)
+CREATE OR REPLACE FUNCTION check_pull_request_reviewers_limit() RETURNS TRIGGER AS $$
+BEGIN
+    IF (SELECT COUNT(*) FROM pull_request_reviewers WHERE pull_request_id = NEW.pull_request_id) > 10 THEN
+        RAISE EXCEPTION 'too many reviewers for pull request %', NEW.pull_request_id;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER pull_request_reviewers_limit
+    AFTER INSERT ON pull_request_reviewers
+    FOR EACH ROW EXECUTE FUNCTION check_pull_request_reviewers_limit();
@@ -0,0 +1,10 @@
+-- "мешок" ротации round_robin: участники, уже выбранные в текущем круге.
+-- когда выбрать больше некого, круг начинается заново
+CREATE TABLE IF NOT EXISTS team_rotation_picks (
+    team_name VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    picked_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (team_name, user_id),
+    FOREIGN KEY (team_name) REFERENCES teams(team_name) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
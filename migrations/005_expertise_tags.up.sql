@@ -0,0 +1,3 @@
+-- экспертиза пользователей и теги, которые требуются от ревьюверов PR
+ALTER TABLE users ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE pull_requests ADD COLUMN required_tags TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,2 @@
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS ownership_match;
+DROP TABLE IF EXISTS team_codeowners;
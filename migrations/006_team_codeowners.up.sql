@@ -0,0 +1,10 @@
+-- файл владельцев кода команды в синтаксисе CODEOWNERS
+CREATE TABLE IF NOT EXISTS team_codeowners (
+    team_name VARCHAR(255) PRIMARY KEY,
+    content TEXT NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (team_name) REFERENCES teams(team_name) ON DELETE CASCADE
+);
+
+-- правило, по которому на PR назначен владелец кода
+ALTER TABLE pull_requests ADD COLUMN ownership_match JSONB;
@@ -0,0 +1,6 @@
+-- уровень участника и политика команды "хотя бы один senior среди ревьюверов"
+ALTER TABLE users
+    ADD COLUMN level VARCHAR(16) NOT NULL DEFAULT 'middle'
+    CHECK (level IN ('junior', 'middle', 'senior', 'lead'));
+
+ALTER TABLE teams ADD COLUMN require_senior BOOLEAN NOT NULL DEFAULT false;
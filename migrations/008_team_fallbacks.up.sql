@@ -0,0 +1,13 @@
+-- команды-партнеры, из которых добираются ревьюверы, если в команде автора не хватает кандидатов
+CREATE TABLE IF NOT EXISTS team_fallbacks (
+    team_name VARCHAR(255) NOT NULL,
+    fallback_team VARCHAR(255) NOT NULL,
+    priority INTEGER NOT NULL,
+    PRIMARY KEY (team_name, fallback_team),
+    FOREIGN KEY (team_name) REFERENCES teams(team_name) ON DELETE CASCADE,
+    FOREIGN KEY (fallback_team) REFERENCES teams(team_name) ON DELETE CASCADE,
+    CHECK (team_name <> fallback_team)
+);
+
+-- команда-партнер, из которой назначен ревьювер (NULL - команда автора)
+ALTER TABLE pull_request_reviewers ADD COLUMN fallback_team VARCHAR(255);
@@ -0,0 +1,3 @@
+-- часовой пояс и рабочее время участника (NULL - без расписания, доступен всегда)
+ALTER TABLE users ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT 'UTC';
+ALTER TABLE users ADD COLUMN working_hours JSONB;
@@ -0,0 +1,2 @@
+-- сколько открытых ревью может быть у пользователя одновременно (NULL - без ограничения)
+ALTER TABLE users ADD COLUMN max_open_reviews INTEGER CHECK (max_open_reviews >= 0);
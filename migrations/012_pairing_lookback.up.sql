@@ -0,0 +1,4 @@
+-- сколько последних PR автора учитывается, чтобы не назначать ему одних и тех же ревьюверов (0 - не учитывать)
+ALTER TABLE teams
+    ADD COLUMN pairing_lookback SMALLINT NOT NULL DEFAULT 0
+    CHECK (pairing_lookback BETWEEN 0 AND 20);
@@ -0,0 +1,15 @@
+-- объяснение каждого назначения ревьюверов: пул кандидатов, исключенные и причины, стратегия и seed
+CREATE TABLE IF NOT EXISTS assignment_explanations (
+    id BIGSERIAL PRIMARY KEY,
+    pull_request_id VARCHAR(255) NOT NULL,
+    kind VARCHAR(32) NOT NULL,
+    strategy VARCHAR(32) NOT NULL,
+    seed BIGINT NOT NULL,
+    candidate_pool TEXT[] NOT NULL DEFAULT '{}',
+    excluded JSONB NOT NULL DEFAULT '[]',
+    selected TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (pull_request_id) REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_assignment_explanations_pr ON assignment_explanations(pull_request_id);
@@ -0,0 +1 @@
+ALTER TABLE teams DROP COLUMN IF EXISTS reassign_on_deactivate;
@@ -0,0 +1,3 @@
+-- при деактивации участника его открытые ревью автоматически переназначаются
+ALTER TABLE teams
+    ADD COLUMN reassign_on_deactivate BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,15 @@
+-- отказы ревьюверов от назначенных PR с причиной и заменой
+CREATE TABLE IF NOT EXISTS review_declines (
+    id BIGSERIAL PRIMARY KEY,
+    pull_request_id VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    reason TEXT NOT NULL,
+    -- NULL - замены не нашлось
+    replaced_by VARCHAR(255),
+    declined_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (pull_request_id) REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE,
+    FOREIGN KEY (replaced_by) REFERENCES users(user_id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_review_declines_user ON review_declines(user_id);
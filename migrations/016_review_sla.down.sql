@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS review_sla_events;
+ALTER TABLE pull_request_reviewers DROP COLUMN IF EXISTS overdue_at;
+ALTER TABLE teams DROP COLUMN IF EXISTS sla_grace_hours;
+ALTER TABLE teams DROP COLUMN IF EXISTS review_sla_hours;
@@ -0,0 +1,24 @@
+-- SLA ревью в рабочих часах ревьювера: по истечении ревью помечается просроченным,
+-- а еще через sla_grace_hours рабочих часов переназначается (0 - SLA не отслеживается)
+ALTER TABLE teams
+    ADD COLUMN review_sla_hours SMALLINT NOT NULL DEFAULT 0
+    CHECK (review_sla_hours BETWEEN 0 AND 720),
+    ADD COLUMN sla_grace_hours SMALLINT NOT NULL DEFAULT 8
+    CHECK (sla_grace_hours BETWEEN 0 AND 720);
+
+ALTER TABLE pull_request_reviewers ADD COLUMN overdue_at TIMESTAMP WITH TIME ZONE;
+
+-- события SLA: просрочка ревью и автоматическое переназначение
+CREATE TABLE IF NOT EXISTS review_sla_events (
+    id BIGSERIAL PRIMARY KEY,
+    pull_request_id VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    kind VARCHAR(32) NOT NULL CHECK (kind IN ('overdue', 'reassigned')),
+    replaced_by VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (pull_request_id) REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE,
+    FOREIGN KEY (replaced_by) REFERENCES users(user_id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_review_sla_events_pr ON review_sla_events(pull_request_id);
@@ -0,0 +1,9 @@
+-- CLOSED - PR брошен и может быть открыт заново, DRAFT - черновик без ревьюверов
+ALTER TABLE pull_requests DROP CONSTRAINT IF EXISTS pull_requests_status_check;
+ALTER TABLE pull_requests
+    ADD CONSTRAINT pull_requests_status_check CHECK (status IN ('OPEN', 'MERGED', 'CLOSED', 'DRAFT'));
+
+ALTER TABLE pull_requests
+    ADD COLUMN closed_at TIMESTAMP WITH TIME ZONE,
+    -- измененные файлы сохраняются, чтобы выбрать владельца кода, когда черновик будет готов к ревью
+    ADD COLUMN changed_files TEXT[] NOT NULL DEFAULT '{}';
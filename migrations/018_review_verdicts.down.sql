@@ -0,0 +1,4 @@
+ALTER TABLE teams DROP COLUMN IF EXISTS required_approvals;
+
+ALTER TABLE pull_request_reviewers DROP COLUMN IF EXISTS verdict_at;
+ALTER TABLE pull_request_reviewers DROP COLUMN IF EXISTS verdict;
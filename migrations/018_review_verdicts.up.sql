@@ -0,0 +1,10 @@
+-- итог ревью каждого ревьювера; NULL - ревьювер еще не ответил
+ALTER TABLE pull_request_reviewers
+    ADD COLUMN verdict VARCHAR(32)
+    CHECK (verdict IN ('APPROVED', 'CHANGES_REQUESTED', 'COMMENTED')),
+    ADD COLUMN verdict_at TIMESTAMP WITH TIME ZONE;
+
+-- сколько одобрений нужно для merge (0 - merge без одобрений)
+ALTER TABLE teams
+    ADD COLUMN required_approvals SMALLINT NOT NULL DEFAULT 0
+    CHECK (required_approvals BETWEEN 0 AND 10);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS review_verdicts;
+DROP TABLE IF EXISTS review_rounds;
@@ -0,0 +1,38 @@
+-- раунды ревью PR: первый начинается, когда PR открывается для ревью,
+-- следующие - при повторном запросе ревью после исправлений
+CREATE TABLE IF NOT EXISTS review_rounds (
+    pull_request_id VARCHAR(255) NOT NULL,
+    round INTEGER NOT NULL CHECK (round > 0),
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (pull_request_id, round),
+    FOREIGN KEY (pull_request_id) REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE
+);
+
+-- история итогов ревью по раундам; requested_at - когда ревью было запрошено у ревьювера в этом раунде
+CREATE TABLE IF NOT EXISTS review_verdicts (
+    id BIGSERIAL PRIMARY KEY,
+    pull_request_id VARCHAR(255) NOT NULL,
+    round INTEGER NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    verdict VARCHAR(32) NOT NULL CHECK (verdict IN ('APPROVED', 'CHANGES_REQUESTED', 'COMMENTED')),
+    requested_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    submitted_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (pull_request_id, round) REFERENCES review_rounds(pull_request_id, round) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_review_verdicts_user ON review_verdicts(user_id);
+
+-- существующие PR (кроме черновиков) находятся в первом раунде
+INSERT INTO review_rounds (pull_request_id, round, started_at)
+SELECT pull_request_id, 1, COALESCE(created_at, CURRENT_TIMESTAMP)
+FROM pull_requests
+WHERE status <> 'DRAFT'
+ON CONFLICT DO NOTHING;
+
+INSERT INTO review_verdicts (pull_request_id, round, user_id, verdict, requested_at, submitted_at)
+SELECT prr.pull_request_id, 1, prr.user_id, prr.verdict,
+       COALESCE(prr.assigned_at, rr.started_at), COALESCE(prr.verdict_at, CURRENT_TIMESTAMP)
+FROM pull_request_reviewers prr
+JOIN review_rounds rr ON rr.pull_request_id = prr.pull_request_id AND rr.round = 1
+WHERE prr.verdict IS NOT NULL;
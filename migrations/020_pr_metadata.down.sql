@@ -0,0 +1,8 @@
+ALTER TABLE teams DROP COLUMN IF EXISTS assignment_rules;
+
+ALTER TABLE pull_requests
+    DROP COLUMN IF EXISTS labels,
+    DROP COLUMN IF EXISTS priority,
+    DROP COLUMN IF EXISTS files_changed,
+    DROP COLUMN IF EXISTS lines_removed,
+    DROP COLUMN IF EXISTS lines_added;
@@ -0,0 +1,11 @@
+-- размер и приоритет PR, по ним правила команды меняют настройки назначения
+ALTER TABLE pull_requests
+    ADD COLUMN lines_added INTEGER NOT NULL DEFAULT 0 CHECK (lines_added >= 0),
+    ADD COLUMN lines_removed INTEGER NOT NULL DEFAULT 0 CHECK (lines_removed >= 0),
+    ADD COLUMN files_changed INTEGER NOT NULL DEFAULT 0 CHECK (files_changed >= 0),
+    ADD COLUMN priority VARCHAR(16) NOT NULL DEFAULT 'normal' CHECK (priority IN ('low', 'normal', 'urgent')),
+    ADD COLUMN labels TEXT[] NOT NULL DEFAULT '{}';
+
+-- правила назначения команды в порядке применения, например
+-- [{"min_lines": 501, "reviewers_per_pr": 3}, {"priority": "urgent", "assignment_strategy": "least_loaded"}]
+ALTER TABLE teams ADD COLUMN assignment_rules JSONB NOT NULL DEFAULT '[]';
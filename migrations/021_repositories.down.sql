@@ -0,0 +1,8 @@
+ALTER TABLE pull_requests
+    DROP CONSTRAINT IF EXISTS pull_requests_repository_number_key,
+    DROP CONSTRAINT IF EXISTS pull_requests_repository_number_check,
+    DROP COLUMN IF EXISTS pr_number,
+    DROP COLUMN IF EXISTS repository_name;
+
+DROP TABLE IF EXISTS repository_teams;
+DROP TABLE IF EXISTS repositories;
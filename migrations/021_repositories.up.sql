@@ -0,0 +1,28 @@
+-- репозитории и их настройки назначения; NULL - используется настройка команды автора PR
+CREATE TABLE IF NOT EXISTS repositories (
+    repository_name VARCHAR(255) PRIMARY KEY CHECK (repository_name <> '' AND position('#' IN repository_name) = 0),
+    reviewers_per_pr SMALLINT CHECK (reviewers_per_pr BETWEEN 1 AND 10),
+    assignment_strategy VARCHAR(32),
+    require_senior BOOLEAN,
+    required_approvals SMALLINT CHECK (required_approvals BETWEEN 0 AND 10),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- команды-владельцы репозитория
+CREATE TABLE IF NOT EXISTS repository_teams (
+    repository_name VARCHAR(255) NOT NULL,
+    team_name VARCHAR(255) NOT NULL,
+    PRIMARY KEY (repository_name, team_name),
+    FOREIGN KEY (repository_name) REFERENCES repositories(repository_name) ON DELETE CASCADE,
+    FOREIGN KEY (team_name) REFERENCES teams(team_name) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_repository_teams_team ON repository_teams(team_name);
+
+-- PR репозитория идентифицируется номером, pull_request_id такого PR - "repository#number".
+-- PR, созданные без репозитория, сохраняют свой идентификатор
+ALTER TABLE pull_requests
+    ADD COLUMN repository_name VARCHAR(255) REFERENCES repositories(repository_name),
+    ADD COLUMN pr_number INTEGER CHECK (pr_number > 0),
+    ADD CONSTRAINT pull_requests_repository_number_check CHECK ((repository_name IS NULL) = (pr_number IS NULL)),
+    ADD CONSTRAINT pull_requests_repository_number_key UNIQUE (repository_name, pr_number);
@@ -0,0 +1,106 @@
+-- возврат к ключам без организации; не выполнится, если одинаковые имена или идентификаторы
+-- уже используются в разных организациях
+DROP INDEX IF EXISTS idx_pull_requests_org_status;
+DROP INDEX IF EXISTS idx_users_org_team;
+
+CREATE OR REPLACE FUNCTION check_pull_request_reviewers_limit() RETURNS TRIGGER AS $$
+BEGIN
+    IF (SELECT COUNT(*) FROM pull_request_reviewers WHERE pull_request_id = NEW.pull_request_id) > 10 THEN
+        RAISE EXCEPTION 'too many reviewers for pull request %', NEW.pull_request_id;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- первичные ключи с организацией удаляются вместе с внешними ключами, которые на них ссылаются
+ALTER TABLE review_rounds DROP CONSTRAINT review_rounds_pkey CASCADE;
+ALTER TABLE pull_requests DROP CONSTRAINT pull_requests_pkey CASCADE;
+ALTER TABLE repositories DROP CONSTRAINT repositories_pkey CASCADE;
+ALTER TABLE users DROP CONSTRAINT users_pkey CASCADE;
+ALTER TABLE teams DROP CONSTRAINT teams_pkey CASCADE;
+
+ALTER TABLE pull_requests DROP CONSTRAINT pull_requests_repository_number_key;
+ALTER TABLE pull_request_reviewers DROP CONSTRAINT pull_request_reviewers_pkey;
+ALTER TABLE team_rotation_picks DROP CONSTRAINT team_rotation_picks_pkey;
+ALTER TABLE team_codeowners DROP CONSTRAINT team_codeowners_pkey;
+ALTER TABLE team_fallbacks DROP CONSTRAINT team_fallbacks_pkey;
+ALTER TABLE repository_teams DROP CONSTRAINT repository_teams_pkey;
+
+ALTER TABLE repository_teams DROP COLUMN org_id;
+ALTER TABLE review_verdicts DROP COLUMN org_id;
+ALTER TABLE review_rounds DROP COLUMN org_id;
+ALTER TABLE review_sla_events DROP COLUMN org_id;
+ALTER TABLE review_declines DROP COLUMN org_id;
+ALTER TABLE assignment_explanations DROP COLUMN org_id;
+ALTER TABLE out_of_office DROP COLUMN org_id;
+ALTER TABLE team_fallbacks DROP COLUMN org_id;
+ALTER TABLE team_codeowners DROP COLUMN org_id;
+ALTER TABLE team_rotation_picks DROP COLUMN org_id;
+ALTER TABLE pull_request_reviewers DROP COLUMN org_id;
+ALTER TABLE repositories DROP COLUMN org_id;
+ALTER TABLE pull_requests DROP COLUMN org_id;
+ALTER TABLE users DROP COLUMN org_id;
+ALTER TABLE teams DROP COLUMN org_id;
+
+ALTER TABLE teams ADD PRIMARY KEY (team_name);
+ALTER TABLE users ADD PRIMARY KEY (user_id);
+ALTER TABLE pull_requests ADD PRIMARY KEY (pull_request_id);
+ALTER TABLE repositories ADD PRIMARY KEY (repository_name);
+ALTER TABLE pull_request_reviewers ADD PRIMARY KEY (pull_request_id, user_id);
+ALTER TABLE team_rotation_picks ADD PRIMARY KEY (team_name, user_id);
+ALTER TABLE team_codeowners ADD PRIMARY KEY (team_name);
+ALTER TABLE team_fallbacks ADD PRIMARY KEY (team_name, fallback_team);
+ALTER TABLE review_rounds ADD PRIMARY KEY (pull_request_id, round);
+ALTER TABLE repository_teams ADD PRIMARY KEY (repository_name, team_name);
+
+ALTER TABLE pull_requests
+    ADD CONSTRAINT pull_requests_repository_number_key UNIQUE (repository_name, pr_number);
+
+ALTER TABLE users
+    ADD FOREIGN KEY (team_name) REFERENCES teams(team_name) ON DELETE CASCADE;
+
+ALTER TABLE pull_requests
+    ADD FOREIGN KEY (author_id) REFERENCES users(user_id),
+    ADD FOREIGN KEY (repository_name) REFERENCES repositories(repository_name);
+
+ALTER TABLE pull_request_reviewers
+    ADD FOREIGN KEY (pull_request_id) REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE,
+    ADD FOREIGN KEY (user_id) REFERENCES users(user_id);
+
+ALTER TABLE team_rotation_picks
+    ADD FOREIGN KEY (team_name) REFERENCES teams(team_name) ON DELETE CASCADE,
+    ADD FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE;
+
+ALTER TABLE team_codeowners
+    ADD FOREIGN KEY (team_name) REFERENCES teams(team_name) ON DELETE CASCADE;
+
+ALTER TABLE team_fallbacks
+    ADD FOREIGN KEY (team_name) REFERENCES teams(team_name) ON DELETE CASCADE,
+    ADD FOREIGN KEY (fallback_team) REFERENCES teams(team_name) ON DELETE CASCADE;
+
+ALTER TABLE out_of_office
+    ADD FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE;
+
+ALTER TABLE assignment_explanations
+    ADD FOREIGN KEY (pull_request_id) REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE;
+
+ALTER TABLE review_declines
+    ADD FOREIGN KEY (pull_request_id) REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE,
+    ADD FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE,
+    ADD FOREIGN KEY (replaced_by) REFERENCES users(user_id) ON DELETE SET NULL;
+
+ALTER TABLE review_sla_events
+    ADD FOREIGN KEY (pull_request_id) REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE,
+    ADD FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE,
+    ADD FOREIGN KEY (replaced_by) REFERENCES users(user_id) ON DELETE SET NULL;
+
+ALTER TABLE review_rounds
+    ADD FOREIGN KEY (pull_request_id) REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE;
+
+ALTER TABLE review_verdicts
+    ADD FOREIGN KEY (pull_request_id, round) REFERENCES review_rounds(pull_request_id, round) ON DELETE CASCADE,
+    ADD FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE;
+
+ALTER TABLE repository_teams
+    ADD FOREIGN KEY (repository_name) REFERENCES repositories(repository_name) ON DELETE CASCADE,
+    ADD FOREIGN KEY (team_name) REFERENCES teams(team_name) ON DELETE CASCADE;
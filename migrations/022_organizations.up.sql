@@ -0,0 +1,132 @@
+-- организация (tenant), которой принадлежат команды, пользователи, PR и репозитории.
+-- Данные, созданные до появления организаций, относятся к организации по умолчанию.
+-- Имена команд и репозиториев, user_id и pull_request_id уникальны в пределах организации:
+-- ключи всех таблиц начинаются с org_id, поэтому связанные записи не выходят за свою организацию
+
+-- старые первичные ключи удаляются вместе с внешними ключами, которые на них ссылаются
+ALTER TABLE review_rounds DROP CONSTRAINT review_rounds_pkey CASCADE;
+ALTER TABLE pull_requests DROP CONSTRAINT pull_requests_pkey CASCADE;
+ALTER TABLE repositories DROP CONSTRAINT repositories_pkey CASCADE;
+ALTER TABLE users DROP CONSTRAINT users_pkey CASCADE;
+ALTER TABLE teams DROP CONSTRAINT teams_pkey CASCADE;
+
+ALTER TABLE pull_requests DROP CONSTRAINT pull_requests_repository_number_key;
+ALTER TABLE pull_request_reviewers DROP CONSTRAINT pull_request_reviewers_pkey;
+ALTER TABLE team_rotation_picks DROP CONSTRAINT team_rotation_picks_pkey;
+ALTER TABLE team_codeowners DROP CONSTRAINT team_codeowners_pkey;
+ALTER TABLE team_fallbacks DROP CONSTRAINT team_fallbacks_pkey;
+ALTER TABLE repository_teams DROP CONSTRAINT repository_teams_pkey;
+
+-- значение по умолчанию нужно только для уже существующих строк, новые записи передают организацию явно
+ALTER TABLE teams ADD COLUMN org_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE users ADD COLUMN org_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE pull_requests ADD COLUMN org_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE repositories ADD COLUMN org_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE pull_request_reviewers ADD COLUMN org_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE team_rotation_picks ADD COLUMN org_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE team_codeowners ADD COLUMN org_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE team_fallbacks ADD COLUMN org_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE out_of_office ADD COLUMN org_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE assignment_explanations ADD COLUMN org_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE review_declines ADD COLUMN org_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE review_sla_events ADD COLUMN org_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE review_rounds ADD COLUMN org_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE review_verdicts ADD COLUMN org_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE repository_teams ADD COLUMN org_id VARCHAR(64) NOT NULL DEFAULT 'default';
+
+ALTER TABLE teams ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE users ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE pull_requests ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE repositories ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE pull_request_reviewers ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE team_rotation_picks ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE team_codeowners ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE team_fallbacks ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE out_of_office ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE assignment_explanations ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE review_declines ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE review_sla_events ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE review_rounds ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE review_verdicts ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE repository_teams ALTER COLUMN org_id DROP DEFAULT;
+
+ALTER TABLE teams ADD PRIMARY KEY (org_id, team_name);
+ALTER TABLE users ADD PRIMARY KEY (org_id, user_id);
+ALTER TABLE pull_requests ADD PRIMARY KEY (org_id, pull_request_id);
+ALTER TABLE repositories ADD PRIMARY KEY (org_id, repository_name);
+ALTER TABLE pull_request_reviewers ADD PRIMARY KEY (org_id, pull_request_id, user_id);
+ALTER TABLE team_rotation_picks ADD PRIMARY KEY (org_id, team_name, user_id);
+ALTER TABLE team_codeowners ADD PRIMARY KEY (org_id, team_name);
+ALTER TABLE team_fallbacks ADD PRIMARY KEY (org_id, team_name, fallback_team);
+ALTER TABLE review_rounds ADD PRIMARY KEY (org_id, pull_request_id, round);
+ALTER TABLE repository_teams ADD PRIMARY KEY (org_id, repository_name, team_name);
+
+ALTER TABLE pull_requests
+    ADD CONSTRAINT pull_requests_repository_number_key UNIQUE (org_id, repository_name, pr_number);
+
+-- те же связи, что и раньше, но внутри организации
+ALTER TABLE users
+    ADD FOREIGN KEY (org_id, team_name) REFERENCES teams(org_id, team_name) ON DELETE CASCADE;
+
+ALTER TABLE pull_requests
+    ADD FOREIGN KEY (org_id, author_id) REFERENCES users(org_id, user_id),
+    ADD FOREIGN KEY (org_id, repository_name) REFERENCES repositories(org_id, repository_name);
+
+ALTER TABLE pull_request_reviewers
+    ADD FOREIGN KEY (org_id, pull_request_id) REFERENCES pull_requests(org_id, pull_request_id) ON DELETE CASCADE,
+    ADD FOREIGN KEY (org_id, user_id) REFERENCES users(org_id, user_id);
+
+ALTER TABLE team_rotation_picks
+    ADD FOREIGN KEY (org_id, team_name) REFERENCES teams(org_id, team_name) ON DELETE CASCADE,
+    ADD FOREIGN KEY (org_id, user_id) REFERENCES users(org_id, user_id) ON DELETE CASCADE;
+
+ALTER TABLE team_codeowners
+    ADD FOREIGN KEY (org_id, team_name) REFERENCES teams(org_id, team_name) ON DELETE CASCADE;
+
+ALTER TABLE team_fallbacks
+    ADD FOREIGN KEY (org_id, team_name) REFERENCES teams(org_id, team_name) ON DELETE CASCADE,
+    ADD FOREIGN KEY (org_id, fallback_team) REFERENCES teams(org_id, team_name) ON DELETE CASCADE;
+
+ALTER TABLE out_of_office
+    ADD FOREIGN KEY (org_id, user_id) REFERENCES users(org_id, user_id) ON DELETE CASCADE;
+
+ALTER TABLE assignment_explanations
+    ADD FOREIGN KEY (org_id, pull_request_id) REFERENCES pull_requests(org_id, pull_request_id) ON DELETE CASCADE;
+
+-- при удалении заменившего пользователя обнуляется только replaced_by, org_id остается
+ALTER TABLE review_declines
+    ADD FOREIGN KEY (org_id, pull_request_id) REFERENCES pull_requests(org_id, pull_request_id) ON DELETE CASCADE,
+    ADD FOREIGN KEY (org_id, user_id) REFERENCES users(org_id, user_id) ON DELETE CASCADE,
+    ADD FOREIGN KEY (org_id, replaced_by) REFERENCES users(org_id, user_id) ON DELETE SET NULL (replaced_by);
+
+ALTER TABLE review_sla_events
+    ADD FOREIGN KEY (org_id, pull_request_id) REFERENCES pull_requests(org_id, pull_request_id) ON DELETE CASCADE,
+    ADD FOREIGN KEY (org_id, user_id) REFERENCES users(org_id, user_id) ON DELETE CASCADE,
+    ADD FOREIGN KEY (org_id, replaced_by) REFERENCES users(org_id, user_id) ON DELETE SET NULL (replaced_by);
+
+ALTER TABLE review_rounds
+    ADD FOREIGN KEY (org_id, pull_request_id) REFERENCES pull_requests(org_id, pull_request_id) ON DELETE CASCADE;
+
+ALTER TABLE review_verdicts
+    ADD FOREIGN KEY (org_id, pull_request_id, round) REFERENCES review_rounds(org_id, pull_request_id, round)
+        ON DELETE CASCADE,
+    ADD FOREIGN KEY (org_id, user_id) REFERENCES users(org_id, user_id) ON DELETE CASCADE;
+
+ALTER TABLE repository_teams
+    ADD FOREIGN KEY (org_id, repository_name) REFERENCES repositories(org_id, repository_name) ON DELETE CASCADE,
+    ADD FOREIGN KEY (org_id, team_name) REFERENCES teams(org_id, team_name) ON DELETE CASCADE;
+
+-- лимит ревьюверов считается по PR своей организации
+CREATE OR REPLACE FUNCTION check_pull_request_reviewers_limit() RETURNS TRIGGER AS $$
+BEGIN
+    IF (SELECT COUNT(*) FROM pull_request_reviewers
+        WHERE org_id = NEW.org_id AND pull_request_id = NEW.pull_request_id) > 10 THEN
+        RAISE EXCEPTION 'too many reviewers for pull request %', NEW.pull_request_id;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- все запросы API фильтруются по организации
+CREATE INDEX IF NOT EXISTS idx_users_org_team ON users(org_id, team_name);
+CREATE INDEX IF NOT EXISTS idx_pull_requests_org_status ON pull_requests(org_id, status);